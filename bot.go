@@ -11,205 +11,538 @@
 package telebot
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"net/url"
-	"time"
+	"sort"
+	"strings"
 )
 
-// Create a bog with the appropriate config.
-func CreateBot(apiToken string, config map[string]string) Bot {
-
-	// handerMap is a map to make the correspondance between events and handlers.
-	handlerMap := make(map[string]map[string]func(u *Update))
+// Create a bot configured with options. Without WithWebhook, the bot fetches updates with long polling.
+// A *ConfigError is returned if the configuration is invalid.
+func CreateBot(apiToken string, opts ...Option) (*Bot, error) {
 
 	// Create the bot.
-	return Bot{apiToken: apiToken, config: config, handlerMap: handlerMap}
+	b := &Bot{
+		apiToken:        apiToken,
+		httpClient:      http.DefaultClient,
+		baseUrl:         telegramApiBaseUrl,
+		retryPolicy:     DefaultRetryPolicy,
+		pollTimeout:     defaultPollTimeout,
+		pollLimit:       defaultPollLimit,
+		shutdownTimeout: defaultShutdownTimeout,
+		listenAddr:      defaultListenAddr,
+		maxBodySize:     defaultMaxBodySize,
+		workers:         defaultWorkers,
+		queueSize:       defaultQueueSize,
+		limiter:         newRateLimiter(DefaultRateLimits),
+		syncCommands:    make(chan struct{}, 1),
+	}
+
+	// Handlers registered on the bot itself belong to the root router.
+	b.router = router{bot: b}
+
+	// Apply options.
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
 }
 
-// Start the bot.
-func (b *Bot) Start() {
+// Start the bot. Start returns once the bot is set up and receives updates in the background
+// until ctx is done or Stop is called. Use Wait to block until the bot is stopped.
+func (b *Bot) Start(ctx context.Context) error {
 
-	// Set the commands of the bot
-	b.setBotCommands()
+	b.mu.Lock()
 
-	// Determine the type of the bot.
-	isWebook := b.config != nil
+	if b.cancel != nil || b.cancelSetUp != nil {
+		b.mu.Unlock()
+		return ErrAlreadyStarted
+	}
 
-	// If the bot uses webhook to get Updates.
-	if isWebook {
+	// The lock is released during the calls to Telegram, so that they do not block Stop, Wait,
+	// DispatchStats and the webhook handler. Stop cancels the set up and waits for it to end.
+	setUpCtx, cancelSetUp := context.WithCancel(ctx)
+	setUpDone := make(chan struct{})
 
-		// Set up webhook.
-		_, err := b.setWebhook()
+	b.cancelSetUp = cancelSetUp
+	b.setUpDone = setUpDone
+	b.done = nil
+	b.mu.Unlock()
 
-		if err != nil {
-			panic(err)
-		}
+	run, err := b.setUp(setUpCtx)
 
-		// Start a webhook bot and handle incoming updates.
-		u, err := url.Parse(b.config["WebhookUrl"])
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
-		if err != nil {
-			panic(err)
-		}
+	// Stop was called or ctx is done while the bot was set up.
+	stopped := setUpCtx.Err() != nil
+
+	b.cancelSetUp = nil
+	b.setUpDone = nil
+	cancelSetUp()
+	close(setUpDone)
+
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	// The bot set up before being stopped shuts down right away, and Stop waits for it.
+	if stopped {
+		cancel()
+	}
 
-		http.HandleFunc(u.Path+b.apiToken, func(w http.ResponseWriter, r *http.Request) {
-			b.handleTelegramWebHook(w, r)
-		})
-		http.ListenAndServeTLS(":8443", b.config["SslCertificate"], b.config["SslPrivkey"], nil)
+	// Handlers are only cancelled if they do not return within the shutdown timeout.
+	handlersCtx, cancelHandlers := context.WithCancel(context.Background())
+	d := b.startDispatcher(handlersCtx, b.workers, b.queueSize)
 
-		// Else, the bot uses getUpdates.
-	} else {
+	b.cancel = cancel
+	b.done = done
+	b.runErr = nil
+	b.dispatcher = d
 
-		// Deactivate previous webhooks if exists.
-		b.deleteWebhook()
+	// Commands changed while the bot runs are sent to Telegram in the background.
+	go b.syncBotCommands(runCtx)
 
-		// Start with no offset.
-		offset := 0
-		for {
-			// Fetch updates twice a second.
-			time.Sleep(500 * time.Millisecond)
-			// Get and dispatch updates. Set a new offset.
-			offset = b.getUpdates(offset)
+	go func() {
+		err := run(runCtx)
+
+		// Drain queued updates and in-flight handlers.
+		d.close()
+		if !waitTimeout(&d.workers, b.shutdownTimeout) && err == nil {
+			err = ErrShutdownTimeout
 		}
 
+		b.mu.Lock()
+		b.cancel = nil
+		b.runErr = err
+		b.mu.Unlock()
+
+		cancel()
+		cancelHandlers()
+		close(done)
+	}()
+
+	return nil
+}
+
+// Set up the reception of updates and return the function receiving them until its context is done.
+// Errors are returned before running in the background, so that they are returned to the caller of Start.
+func (b *Bot) setUp(ctx context.Context) (func(ctx context.Context) error, error) {
+
+	// Get the username of the bot, to recognize the commands addressed to it.
+	me, err := b.GetMeCtx(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.username = me.Username
+
+	// Set the commands of the bot. Changes already pending are included.
+	select {
+	case <-b.syncCommands:
+	default:
+	}
+
+	b.setBotCommands(ctx)
+
+	// If the bot uses webhook to get Updates, set up webhook and its server.
+	if b.mode == WebhookMode {
+		return b.startWebhook(ctx)
+	}
+
+	// Else, the bot uses getUpdates. Deactivate previous webhooks if exists.
+	if _, err := b.deleteWebhook(ctx); err != nil {
+		return nil, err
 	}
+
+	return func(ctx context.Context) error {
+		// Fetch and dispatch updates with long polling.
+		b.pollUpdates(ctx)
+		return nil
+	}, nil
 }
 
-// Call the handler corresponding to a pair (event, filter) id it exists.
-func (b *Bot) dispatchEvent(event Event, filter string, u *Update) {
+// Stop the bot: stop receiving updates and wait for in-flight handlers to return, within the shutdown timeout.
+// The error which stopped the bot, if any, is returned.
+func (b *Bot) Stop() error {
 
-	eventMap := b.handlerMap[event.Identifier]
+	// A bot being set up stops before receiving updates.
+	b.mu.Lock()
+	cancelSetUp, setUpDone := b.cancelSetUp, b.setUpDone
+	b.mu.Unlock()
 
-	// Get all keys of the eventMap
-	keys := make([]string, len(eventMap))
+	if cancelSetUp != nil {
+		cancelSetUp()
+		<-setUpDone
+	}
 
-	i := 0
-	for k := range eventMap {
-		keys[i] = k
-		i += 1
+	b.mu.Lock()
+	cancel := b.cancel
+	b.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
 	}
 
-	// Dispatch handler if checker is true for each key
-	for _, k := range keys {
+	return b.Wait()
+}
 
-		if event.Checker(k, filter) {
+// Block until the bot is stopped and return the error which stopped it, if any.
+func (b *Bot) Wait() error {
 
-			eventMap[k](u)
+	// Wait for the bot being set up to start, or to fail.
+	b.mu.Lock()
+	setUpDone := b.setUpDone
+	b.mu.Unlock()
+
+	if setUpDone != nil {
+		<-setUpDone
+	}
+
+	b.mu.Lock()
+	done := b.done
+	b.mu.Unlock()
+
+	if done == nil {
+		return ErrNotStarted
+	}
+
+	<-done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.runErr
+}
+
+// Run the handler through the global middlewares.
+func (b *Bot) runHandler(middlewares []Middleware, handler HandlerFunc, c *Context) {
+
+	if err := chainMiddlewares(middlewares, handler)(c); err != nil {
+		b.handleError(c, err)
+	}
+}
+
+// Dispatch an update to the matching handlers, by decreasing priority then in registration order,
+// until one of them stops the propagation. The default handler runs if no handler matched.
+func (b *Bot) dispatchUpdate(ctx context.Context, u *Update) {
+
+	// All the handlers of the update share the same context.
+	c := newContext(ctx, b, u)
+
+	// Handlers registered or unregistered while the update is dispatched only apply to the next updates.
+	b.registryMu.RLock()
+	handlers := b.handlers
+	defaultHandler := b.defaultHandler
+	middlewares := b.globalMiddlewares
+	b.registryMu.RUnlock()
+
+	matched := false
+
+	for _, h := range handlers {
+
+		if !h.match(c) {
+			continue
+		}
+
+		matched = true
+		b.runHandler(middlewares, h.handler, c)
+
+		if c.stopped {
+			return
 		}
 	}
 
+	if !matched && defaultHandler != nil {
+		b.runHandler(middlewares, defaultHandler.handler, c)
+	}
 }
 
-// Dispatch an update to the corresponding handler based on the detected event.
-func (b *Bot) dispatchUpdate(u *Update) {
+// Return the text the filters of event are checked against, or false if the update cannot match event.
+func (b *Bot) eventText(event Event, u *Update) (string, bool) {
+
+	switch event.Identifier {
+	case ONCOMMAND.Identifier:
+		if u.Message == nil {
+			return "", false
+		}
+
+		// Commands addressed to other bots are ignored.
+		command, ok := ParseCommand(u.Message.Text)
+		return u.Message.Text, ok && b.isOwnCommand(command)
+	case ONTEXT.Identifier:
+		if u.Message == nil {
+			return "", false
+		}
 
-	// Find the corresponding event and dispatch it.
-	switch {
-	case u.Message.Text != "":
-		b.dispatchEvent(ONCOMMAND, u.Message.Text, u)
-		b.dispatchEvent(ONTEXT, u.Message.Text, u)
-	case u.CallbackQuery.Data != "":
-		b.dispatchEvent(ONCALLBACK, u.CallbackQuery.Data, u)
-		b.dispatchEvent(ONPAYLOAD, u.CallbackQuery.Data, u)
+		return u.Message.Text, u.Message.Text != ""
+	case ONCALLBACK.Identifier, ONPAYLOAD.Identifier:
+		if u.CallbackQuery == nil {
+			return "", false
+		}
+
+		return u.CallbackQuery.Data, u.CallbackQuery.Data != ""
 	}
+
+	text := updateText(u)
+
+	return text, text != ""
 }
 
-// Register the handler corresponding to the pair (event, filter)
-func (b *Bot) registerHandler(event Event, filter string, handler func(u *Update)) {
-	// Check if event is already registered.
-	_, exists := b.handlerMap[event.Identifier]
+// Pass an error returned by a handler to the error handler of the bot, or log it.
+func (b *Bot) handleError(c *Context, err error) {
 
-	// If the event doesn't exist, create a new eventMap and register the handler.
-	if !exists {
-		eventMap := make(map[string]func(u *Update))
-		eventMap[filter] = handler
-		b.handlerMap[event.Identifier] = eventMap
+	b.registryMu.RLock()
+	errorHandler := b.errorHandler
+	b.registryMu.RUnlock()
 
-		// Otherwise, register the handler.
-	} else {
-		b.handlerMap[event.Identifier][filter] = handler
+	if errorHandler != nil {
+		errorHandler(c, err)
+		return
 	}
+
+	log.Printf("Error handling update %d: %s", c.Update.UpdateId, err.Error())
 }
 
-// Register a command in the bot commands
-func (b *Bot) registerInCommands(command string, description string) {
+// Set the function handling the errors returned by handlers. By default, errors are logged.
+func (b *Bot) OnError(handler func(c *Context, err error)) {
 
-	// Only append commands with description >= 3 (otherwise Telegram will ignore it)
-	if len(description) >= 3 {
-		b.commands = append(b.commands, BotCommand{command, description})
+	b.registryMu.Lock()
+	defer b.registryMu.Unlock()
+
+	b.errorHandler = handler
+}
+
+// Create the handler entry corresponding to the pair (event, filter)
+func (b *Bot) eventHandler(event Event, filter string, handler HandlerFunc, priority int) *handlerEntry {
+
+	match := func(c *Context) bool {
+		text, ok := b.eventText(event, c.Update)
+		return ok && event.Checker(filter, text)
 	}
+
+	return &handlerEntry{match: match, handler: handler, priority: priority}
 }
 
-// Set the bot commands with Telegram API
-func (b *Bot) setBotCommands() {
+// Register a handler entry. It runs after the handlers of higher or equal priority registered before.
+func (b *Bot) registerHandler(entry *handlerEntry) {
 
-	// If no commands are specified, reset bot commands
-	commands := "[]"
+	b.registryMu.Lock()
+	defer b.registryMu.Unlock()
 
-	if len(b.commands) > 0 {
-		jsonCommands, err := json.Marshal(b.commands)
+	b.insertHandler(entry)
+}
 
-		if err != nil {
-			log.Println(err)
-		}
+// Register the handler entry of a command and the command in the bot commands.
+func (b *Bot) registerCommandHandler(entry *handlerEntry, description string) {
 
-		commands = string(jsonCommands)
+	b.registryMu.Lock()
+	changed := b.registerInCommands(entry.command, description)
+	b.insertHandler(entry)
+	b.registryMu.Unlock()
+
+	if changed {
+		b.commandsChanged()
 	}
+}
 
-	val := url.Values{
-		"commands": {commands},
+// Insert entry in the handlers, sorted by decreasing priority. The caller holds registryMu.
+// The handlers are copied on write, so that dispatched updates keep iterating over their own copy.
+func (b *Bot) insertHandler(entry *handlerEntry) {
+
+	i := sort.Search(len(b.handlers), func(i int) bool {
+		return b.handlers[i].priority < entry.priority
+	})
+
+	handlers := make([]*handlerEntry, 0, len(b.handlers)+1)
+	handlers = append(handlers, b.handlers[:i]...)
+	handlers = append(handlers, entry)
+	handlers = append(handlers, b.handlers[i:]...)
+
+	b.handlers = handlers
+}
+
+// Set the default handler entry, replacing the previous one.
+func (b *Bot) setDefaultHandler(entry *handlerEntry) {
+
+	b.registryMu.Lock()
+	defer b.registryMu.Unlock()
+
+	b.defaultHandler = entry
+}
+
+// Unregister the handler entry. Return false if it was not registered.
+func (b *Bot) unregisterHandler(entry *handlerEntry) bool {
+
+	b.registryMu.Lock()
+
+	if b.defaultHandler == entry {
+		b.defaultHandler = nil
+		b.registryMu.Unlock()
+		return true
 	}
 
-	_, err := b.makeAPICall(setMyCommandsEndpoint, val)
+	for i, h := range b.handlers {
+		if h != entry {
+			continue
+		}
 
-	if err != nil {
-		log.Println(err)
+		handlers := make([]*handlerEntry, 0, len(b.handlers)-1)
+		handlers = append(handlers, b.handlers[:i]...)
+		b.handlers = append(handlers, b.handlers[i+1:]...)
+
+		// Remove the command once its last handler is unregistered.
+		changed := entry.command != "" && !b.hasCommandHandler(entry.command) && b.unregisterInCommands(entry.command)
+		b.registryMu.Unlock()
+
+		if changed {
+			b.commandsChanged()
+		}
+
+		return true
+	}
+
+	b.registryMu.Unlock()
+
+	return false
+}
+
+// Check if a handler is registered for the command. The caller holds registryMu.
+func (b *Bot) hasCommandHandler(command string) bool {
+
+	for _, h := range b.handlers {
+		if strings.EqualFold(h.command, command) {
+			return true
+		}
 	}
 
+	return false
 }
 
-//
-// Below are defined the module API functions used to link handlers to events.
-//
+// Register a command in the bot commands. A command registered again keeps its last description.
+// Return true if the bot commands changed. The caller holds registryMu.
+func (b *Bot) registerInCommands(command string, description string) bool {
 
-// Trigger handler if the text of the update matches the variable text.
-func (b *Bot) OnText(text string, handler func(u *Update)) {
+	// Only append commands with description >= 3 (otherwise Telegram will ignore it)
+	if len(description) < 3 {
+		return false
+	}
 
-	event := ONTEXT
+	commands := make([]BotCommand, len(b.commands))
+	copy(commands, b.commands)
 
-	// Register handler.
-	b.registerHandler(event, text, handler)
+	for i := range commands {
+		if strings.EqualFold(commands[i].Command, command) {
+			if commands[i].Description == description {
+				return false
+			}
+
+			commands[i].Description = description
+			b.commands = commands
+			return true
+		}
+	}
+
+	b.commands = append(commands, BotCommand{command, description})
+
+	return true
+}
+
+// Remove a command from the bot commands. Return true if the bot commands changed. The caller holds registryMu.
+func (b *Bot) unregisterInCommands(command string) bool {
+
+	for i := range b.commands {
+		if strings.EqualFold(b.commands[i].Command, command) {
+			commands := make([]BotCommand, 0, len(b.commands)-1)
+			commands = append(commands, b.commands[:i]...)
+			b.commands = append(commands, b.commands[i+1:]...)
+			return true
+		}
+	}
+
+	return false
 }
 
-// Match commands (i.e. when text starts with the filter but can contain more text)
-func (b *Bot) OnCommand(text string, description string, handler func(u *Update)) {
+// Notify the running bot that its commands changed. Notifications are coalesced: the commands are sent once
+// to Telegram for several changes made in a row.
+func (b *Bot) commandsChanged() {
 
-	event := ONCOMMAND
+	select {
+	case b.syncCommands <- struct{}{}:
+	default:
+	}
+}
 
-	// Register handler.
-	b.registerHandler(event, text, handler)
+// Send the bot commands to Telegram each time they change, until ctx is done.
+func (b *Bot) syncBotCommands(ctx context.Context) {
 
-	// Register the command in the commandMap
-	b.registerInCommands(text, description)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.syncCommands:
+			b.setBotCommands(ctx)
+		}
+	}
+}
 
+// Get the user of the bot.
+func (b *Bot) GetMe() (*User, error) {
+	return b.GetMeCtx(context.Background())
 }
 
-// Match CallbackQuery
-func (b *Bot) OnCallback(data string, handler func(u *Update)) {
+// GetMe with a context controlling the call.
+func (b *Bot) GetMeCtx(ctx context.Context) (*User, error) {
+
+	var user User
 
-	event := ONCALLBACK
+	if err := b.makeAPICall(ctx, getMeEndpoint, url.Values{}, &user); err != nil {
+		return nil, err
+	}
 
-	// Register handler.
-	b.registerHandler(event, data, handler)
+	return &user, nil
 }
 
-// Match CallbackQuery with payload
-func (b *Bot) OnPayload(data string, handler func(u *Update)) {
+// Set the bot commands with Telegram API
+func (b *Bot) setBotCommands(ctx context.Context) {
+
+	b.registryMu.RLock()
+	botCommands := b.commands
+	b.registryMu.RUnlock()
 
-	event := ONPAYLOAD
+	// If no commands are specified, reset bot commands
+	commands := "[]"
+
+	if len(botCommands) > 0 {
+		jsonCommands, err := json.Marshal(botCommands)
+
+		if err != nil {
+			log.Println(err)
+		}
+
+		commands = string(jsonCommands)
+	}
+
+	val := url.Values{
+		"commands": {commands},
+	}
+
+	err := b.makeAPICall(ctx, setMyCommandsEndpoint, val, nil)
+
+	if err != nil {
+		log.Println(err)
+	}
 
-	// Register handler.
-	b.registerHandler(event, data, handler)
 }
@@ -0,0 +1,205 @@
+package telebot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Start a fake Telegram API answering each method with the result returned by answer.
+func newFakeAPI(t *testing.T, answer func(r *http.Request, method string) interface{}) *httptest.Server {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := answer(r, path.Base(r.URL.Path))
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// Answer getUpdates calls with no update after a short long polling.
+func emptyPoll(r *http.Request) interface{} {
+
+	select {
+	case <-r.Context().Done():
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	return []Update{}
+}
+
+func TestStartDoesNotHoldLockDuringSetUp(t *testing.T) {
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	server := newFakeAPI(t, func(r *http.Request, method string) interface{} {
+		switch method {
+		case "getMe":
+			close(entered)
+			<-release
+			return User{Username: "bot"}
+		case "getUpdates":
+			return emptyPoll(r)
+		}
+		return true
+	})
+
+	b, err := CreateBot("token", WithBaseURL(server.URL))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan error, 1)
+	go func() {
+		started <- b.Start(context.Background())
+	}()
+
+	<-entered
+
+	// The bot is being set up: calls on the bot return without waiting for Telegram.
+	returned := make(chan struct{})
+	go func() {
+		b.DispatchStats()
+		if err := b.Start(context.Background()); err != ErrAlreadyStarted {
+			t.Errorf("Start() while starting error = %v, want %v", err, ErrAlreadyStarted)
+		}
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("calls on the bot blocked while Start was waiting for Telegram")
+	}
+
+	close(release)
+
+	if err := <-started; err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if err := b.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+}
+
+func TestStopDuringSetUp(t *testing.T) {
+
+	entered := make(chan struct{})
+
+	// getMe only returns once the call is cancelled.
+	server := newFakeAPI(t, func(r *http.Request, method string) interface{} {
+		if method == "getMe" {
+			close(entered)
+			<-r.Context().Done()
+		}
+		return true
+	})
+
+	b, err := CreateBot("token", WithBaseURL(server.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan error, 1)
+	go func() {
+		started <- b.Start(context.Background())
+	}()
+
+	<-entered
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- b.Stop()
+	}()
+
+	select {
+	case err := <-stopped:
+		if err != ErrNotStarted {
+			t.Errorf("Stop() during set up error = %v, want %v", err, ErrNotStarted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop() did not cancel the set up")
+	}
+
+	if err := <-started; err == nil {
+		t.Error("Start() stopped during set up returned no error")
+	}
+
+	// Nothing is left running.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cancel != nil || b.cancelSetUp != nil {
+		t.Error("the bot is still running after Stop")
+	}
+}
+
+func TestPollingOffsetKeptAcrossRestarts(t *testing.T) {
+
+	var mu sync.Mutex
+	var offsets []string
+
+	server := newFakeAPI(t, func(r *http.Request, method string) interface{} {
+		switch method {
+		case "getMe":
+			return User{Username: "bot"}
+		case "getUpdates":
+			mu.Lock()
+			offsets = append(offsets, r.FormValue("offset"))
+			mu.Unlock()
+
+			if r.FormValue("offset") == "0" {
+				return []Update{{UpdateId: 41}}
+			}
+			return emptyPoll(r)
+		}
+		return true
+	})
+
+	b, err := CreateBot("token", WithBaseURL(server.URL))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait until the update was confirmed by the next poll, then restart.
+	confirmed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(offsets) > 1
+	}
+
+	for run := 0; run < 2; run++ {
+		if err := b.Start(context.Background()); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+
+		for deadline := time.Now().Add(time.Second); !confirmed() && time.Now().Before(deadline); {
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		if err := b.Stop(); err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, offset := range offsets[1:] {
+		if offset != strconv.Itoa(42) {
+			t.Errorf("offset of poll %d = %s, want 42 (all offsets: %v)", i+1, offset, offsets)
+		}
+	}
+}
@@ -1,23 +1,34 @@
 package telebot
 
 import (
+	"context"
 	"net/url"
 	"strconv"
 )
 
 // Answer a callback query without notification
-func (b *Bot) AnswerCallbackQuery(callbackQueryId string) (string, error) {
+func (b *Bot) AnswerCallbackQuery(callbackQueryId string) (bool, error) {
+	return b.AnswerCallbackQueryCtx(context.Background(), callbackQueryId)
+}
+
+// AnswerCallbackQuery with a context controlling the call.
+func (b *Bot) AnswerCallbackQueryCtx(ctx context.Context, callbackQueryId string) (bool, error) {
 
 	val := url.Values{
 		"callback_query_id": {callbackQueryId},
 	}
 
-	return b.makeAPICall(answerCallbackQueryEndpoint, val)
+	return b.makeBoolAPICall(ctx, answerCallbackQueryEndpoint, val)
 
 }
 
 // Answer a callback query with notification
-func (b *Bot) AnswerCallbackQueryNotification(callbackQueryId string, text string, showAlert bool) (string, error) {
+func (b *Bot) AnswerCallbackQueryNotification(callbackQueryId string, text string, showAlert bool) (bool, error) {
+	return b.AnswerCallbackQueryNotificationCtx(context.Background(), callbackQueryId, text, showAlert)
+}
+
+// AnswerCallbackQueryNotification with a context controlling the call.
+func (b *Bot) AnswerCallbackQueryNotificationCtx(ctx context.Context, callbackQueryId string, text string, showAlert bool) (bool, error) {
 
 	val := url.Values{
 		"callback_query_id": {callbackQueryId},
@@ -25,6 +36,6 @@ func (b *Bot) AnswerCallbackQueryNotification(callbackQueryId string, text strin
 		"show_alert":        {strconv.FormatBool(showAlert)},
 	}
 
-	return b.makeAPICall(answerCallbackQueryEndpoint, val)
+	return b.makeBoolAPICall(ctx, answerCallbackQueryEndpoint, val)
 
 }
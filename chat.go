@@ -1,28 +1,39 @@
 package telebot
 
 import (
+	"context"
 	"net/url"
 	"strconv"
 )
 
 // Kick an user from a group.
-func (b *Bot) KickChatMember(chatId int, userId int) (string, error) {
+func (b *Bot) KickChatMember(chatId int64, userId int64) (bool, error) {
+	return b.KickChatMemberCtx(context.Background(), chatId, userId)
+}
+
+// KickChatMember with a context controlling the call.
+func (b *Bot) KickChatMemberCtx(ctx context.Context, chatId int64, userId int64) (bool, error) {
 
 	val := url.Values{
-		"chat_id": {strconv.Itoa(chatId)},
-		"user_id": {strconv.Itoa(userId)},
+		"chat_id": {strconv.FormatInt(chatId, 10)},
+		"user_id": {strconv.FormatInt(userId, 10)},
 	}
 
-	return b.makeAPICall(kickChatMemberEndpoint, val)
+	return b.makeBoolAPICall(ctx, kickChatMemberEndpoint, val)
 }
 
 // Unban a member from a group.
-func (b *Bot) UnbanChatMember(chatId int, userId int) (string, error) {
+func (b *Bot) UnbanChatMember(chatId int64, userId int64) (bool, error) {
+	return b.UnbanChatMemberCtx(context.Background(), chatId, userId)
+}
+
+// UnbanChatMember with a context controlling the call.
+func (b *Bot) UnbanChatMemberCtx(ctx context.Context, chatId int64, userId int64) (bool, error) {
 
 	val := url.Values{
-		"chat_id": {strconv.Itoa(chatId)},
-		"user_id": {strconv.Itoa(userId)},
+		"chat_id": {strconv.FormatInt(chatId, 10)},
+		"user_id": {strconv.FormatInt(userId, 10)},
 	}
 
-	return b.makeAPICall(unbanChatMemberEndpoint, val)
+	return b.makeBoolAPICall(ctx, unbanChatMemberEndpoint, val)
 }
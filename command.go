@@ -0,0 +1,117 @@
+package telebot
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Command is a bot command parsed from the text of a message, such as `/start@MyBot first "second arg"`.
+type Command struct {
+	// Name of the command, without the leading slash.
+	Name string
+	// Username of the bot the command is addressed to, empty if the command is not addressed to a specific bot.
+	Bot string
+	// Arguments of the command, split on whitespaces. Quoted arguments can contain whitespaces.
+	Args []string
+	// Raw text following the command.
+	Payload string
+}
+
+// Parse the command at the beginning of text. The second value is false if text does not start with a command.
+func ParseCommand(text string) (*Command, bool) {
+
+	if !strings.HasPrefix(text, "/") {
+		return nil, false
+	}
+
+	// The command ends at the first whitespace.
+	token := text
+	payload := ""
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		token = text[:i]
+		payload = strings.TrimSpace(text[i:])
+	}
+
+	name := token[1:]
+	bot := ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, bot = name[:i], name[i+1:]
+	}
+
+	if !isCommandName(name) {
+		return nil, false
+	}
+
+	return &Command{Name: name, Bot: bot, Args: splitArgs(payload), Payload: payload}, true
+}
+
+// Check if name is a valid command name: letters, digits and underscores only.
+func isCommandName(name string) bool {
+
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Split the arguments of a command on whitespaces. Whitespaces between single or double quotes are kept,
+// and a backslash escapes the next character outside single quotes.
+func splitArgs(payload string) []string {
+
+	var args []string
+	var arg strings.Builder
+
+	inArg := false
+	quote := rune(0)
+	escaped := false
+
+	for _, r := range payload {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args
+}
+
+// Return the name of a command registered with or without its leading slash.
+func commandName(command string) string {
+	return strings.TrimPrefix(command, "/")
+}
+
+// Check if a command parsed from a message is for this bot: commands addressed to other bots are ignored.
+func (b *Bot) isOwnCommand(command *Command) bool {
+	return command.Bot == "" || b.username == "" || strings.EqualFold(command.Bot, b.username)
+}
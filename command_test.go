@@ -0,0 +1,91 @@
+package telebot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+
+	tests := []struct {
+		payload string
+		want    []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"a b  c", []string{"a", "b", "c"}},
+		{" \ta\n b ", []string{"a", "b"}},
+		{`"a b" c`, []string{"a b", "c"}},
+		{`'a b' c`, []string{"a b", "c"}},
+		{`a"b c"d`, []string{"ab cd"}},
+		{`"" ''`, []string{"", ""}},
+		{`"it's"`, []string{"it's"}},
+		{`'say "hi"'`, []string{`say "hi"`}},
+		{`a\ b`, []string{"a b"}},
+		{`\"a\"`, []string{`"a"`}},
+		{`"a \" b"`, []string{`a " b`}},
+		{`'a\b'`, []string{`a\b`}},
+		{`a\\b`, []string{`a\b`}},
+		{`"unterminated quote`, []string{"unterminated quote"}},
+		{`trailing\`, []string{"trailing"}},
+	}
+
+	for _, test := range tests {
+		if got := splitArgs(test.payload); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", test.payload, got, test.want)
+		}
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+
+	tests := []struct {
+		text string
+		want *Command
+	}{
+		{"/start", &Command{Name: "start"}},
+		{"/start@MyBot", &Command{Name: "start", Bot: "MyBot"}},
+		{"/start@MyBot hello world", &Command{Name: "start", Bot: "MyBot", Args: []string{"hello", "world"}, Payload: "hello world"}},
+		{"/echo  \"a b\"  ", &Command{Name: "echo", Args: []string{"a b"}, Payload: `"a b"`}},
+		{"/set_name_2\nvalue", &Command{Name: "set_name_2", Args: []string{"value"}, Payload: "value"}},
+		{"start", nil},
+		{"/", nil},
+		{"/@MyBot", nil},
+		{"/start-now", nil},
+		{"/ start", nil},
+	}
+
+	for _, test := range tests {
+		got, ok := ParseCommand(test.text)
+
+		if ok != (test.want != nil) || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseCommand(%q) = %+v, %v, want %+v", test.text, got, ok, test.want)
+		}
+	}
+}
+
+func TestIsOwnCommand(t *testing.T) {
+
+	tests := []struct {
+		username string
+		text     string
+		want     bool
+	}{
+		{"MyBot", "/start", true},
+		{"MyBot", "/start@MyBot", true},
+		{"MyBot", "/start@mybot", true},
+		{"MyBot", "/start@OtherBot", false},
+		{"MyBot", "/start@MyBot2", false},
+		// The username is unknown before the bot is started.
+		{"", "/start@OtherBot", true},
+	}
+
+	for _, test := range tests {
+		b := &Bot{username: test.username}
+		command, _ := ParseCommand(test.text)
+
+		if got := b.isOwnCommand(command); got != test.want {
+			t.Errorf("isOwnCommand(%q) with username %q = %v, want %v", test.text, test.username, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package telebot
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Mode selects how the bot receives updates.
+type Mode int
+
+const (
+	// Fetch updates with long polling on the /getUpdates endpoint.
+	PollingMode Mode = iota
+	// Receive updates on a webhook.
+	WebhookMode
+)
+
+// Return the name of the mode.
+func (m Mode) String() string {
+
+	switch m {
+	case PollingMode:
+		return "polling"
+	case WebhookMode:
+		return "webhook"
+	}
+
+	return "unknown"
+}
+
+// WebhookConfig holds the settings of a bot receiving updates on a webhook.
+type WebhookConfig struct {
+	// Public HTTPS URL of the webhook. The API token is appended to it.
+	Url string
+	// IP address used by Telegram to send requests instead of the one resolved through DNS.
+	IpAddress string
+	// Paths to the .crt certificate and .key private key of the webhook server.
+	// They are not used when the webhook is served over plain HTTP or by your own server.
+	SslCertificate string
+	SslPrivkey     string
+}
+
+// ConfigError is returned by CreateBot when the configuration of the bot is invalid.
+type ConfigError struct {
+	Problems []string
+}
+
+// Error implements the error interface.
+func (e *ConfigError) Error() string {
+	return "telebot: invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Characters allowed in the secret token of a webhook.
+var secretTokenRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// Check the configuration of the bot. A *ConfigError listing all the problems found is returned.
+func (b *Bot) validate() error {
+
+	var problems []string
+	addProblem := func(problem string) {
+		problems = append(problems, problem)
+	}
+
+	if b.apiToken == "" {
+		addProblem("the API token is empty")
+	}
+
+	if _, err := url.Parse(b.baseUrl); err != nil || b.baseUrl == "" {
+		addProblem("the base URL is invalid")
+	}
+
+	if b.httpClient == nil {
+		addProblem("the HTTP client is nil")
+	}
+
+	switch b.mode {
+	case PollingMode:
+		if b.webhook != (WebhookConfig{}) {
+			addProblem("webhook settings are set but the bot is in polling mode")
+		}
+
+		if b.pollTimeout < 0 {
+			addProblem("the poll timeout is negative")
+		}
+
+		// Otherwise, every poll fails with a timeout of the HTTP client.
+		if b.httpClient != nil && b.httpClient.Timeout != 0 && b.httpClient.Timeout <= b.pollTimeout {
+			addProblem("the timeout of the HTTP client must be longer than the poll timeout")
+		}
+
+		if b.pollLimit < 0 || b.pollLimit > 100 {
+			addProblem("the poll limit must be between 1 and 100")
+		}
+
+	case WebhookMode:
+		if u, err := url.Parse(b.webhook.Url); err != nil || u.Scheme != "https" || u.Host == "" {
+			addProblem("the webhook URL must be an absolute https URL")
+		}
+
+		if b.webhook.IpAddress != "" && net.ParseIP(b.webhook.IpAddress) == nil {
+			addProblem("the webhook IP address is invalid")
+		}
+
+		// The certificate and its key are only needed when the bot serves TLS itself.
+		servesTLS := !b.plainHTTP && !b.noWebhookServer && (b.webhookServer == nil || b.webhookServer.TLSConfig == nil)
+		if servesTLS {
+			files := []struct{ name, path string }{
+				{"SSL certificate", b.webhook.SslCertificate},
+				{"SSL private key", b.webhook.SslPrivkey},
+			}
+
+			for _, file := range files {
+				if name, path := file.name, file.path; path == "" {
+					addProblem("the " + name + " is required to serve the webhook over TLS")
+				} else if _, err := os.Stat(path); err != nil {
+					addProblem("the " + name + " is not readable: " + err.Error())
+				}
+			}
+		}
+
+		if b.webhookCertificate != "" {
+			if _, err := os.Stat(b.webhookCertificate); err != nil {
+				addProblem("the webhook certificate is not readable: " + err.Error())
+			}
+		}
+
+		if b.secretToken != "" && !secretTokenRegexp.MatchString(b.secretToken) {
+			addProblem("the secret token must be 1-256 characters among A-Z, a-z, 0-9, _ and -")
+		}
+
+		if b.maxConnections < 0 || b.maxConnections > 100 {
+			addProblem("the maximum number of connections must be between 1 and 100")
+		}
+
+		if b.maxBodySize <= 0 {
+			addProblem("the maximum body size must be positive")
+		}
+
+	default:
+		addProblem("unknown mode " + b.mode.String())
+	}
+
+	if b.workers < 1 {
+		addProblem("the number of workers must be at least 1")
+	}
+
+	if b.queueSize < 0 {
+		addProblem("the queue size is negative")
+	}
+
+	if b.shutdownTimeout < 0 {
+		addProblem("the shutdown timeout is negative")
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+
+	return nil
+}
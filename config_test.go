@@ -0,0 +1,49 @@
+package telebot
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateBotValidation(t *testing.T) {
+
+	webhook := WithWebhook(WebhookConfig{Url: "https://example.com/hook"})
+
+	tests := []struct {
+		name string
+		opts []Option
+		// Part of the problem reported, empty if the configuration is valid.
+		problem string
+	}{
+		{"defaults", nil, ""},
+		{"client timeout longer than the poll timeout", []Option{WithHTTPClient(&http.Client{Timeout: time.Minute})}, ""},
+		{"client without timeout", []Option{WithHTTPClient(&http.Client{}), WithPollTimeout(time.Hour)}, ""},
+		{"client timeout shorter than the poll timeout", []Option{WithHTTPClient(&http.Client{Timeout: 10 * time.Second})}, "longer than the poll timeout"},
+		{"client timeout equal to the poll timeout", []Option{WithHTTPClient(&http.Client{Timeout: time.Second}), WithPollTimeout(time.Second)}, "longer than the poll timeout"},
+		{"client timeout with a webhook", []Option{webhook, WithPlainHTTP(), WithHTTPClient(&http.Client{Timeout: 10 * time.Second})}, ""},
+		{"negative poll timeout", []Option{WithPollTimeout(-time.Second)}, "poll timeout is negative"},
+		{"no workers", []Option{WithWorkers(0)}, "number of workers"},
+		{"nil client", []Option{WithHTTPClient(nil)}, "HTTP client is nil"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := CreateBot("token", test.opts...)
+
+			if test.problem == "" {
+				if err != nil {
+					t.Errorf("CreateBot() error = %v", err)
+				}
+				return
+			}
+
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) || !strings.Contains(err.Error(), test.problem) {
+				t.Errorf("CreateBot() error = %v, want a *ConfigError about %q", err, test.problem)
+			}
+		})
+	}
+}
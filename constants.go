@@ -1,9 +1,40 @@
 package telebot
 
-import "regexp"
+import (
+	"net"
+	"strings"
+	"time"
+)
 
 // Telegram API URL.
-const telegramApiBaseUrl string = "https://api.telegram.org/bot"
+const telegramApiBaseUrl string = "https://api.telegram.org"
+
+// Long polling settings.
+const defaultPollTimeout time.Duration = 30 * time.Second
+const defaultPollLimit int = 100
+const pollMinBackoff time.Duration = time.Second
+const pollMaxBackoff time.Duration = time.Minute
+
+// Maximum time given to in-flight handlers to return when the bot stops.
+const defaultShutdownTimeout time.Duration = 10 * time.Second
+
+// Address on which the webhook server listens.
+const defaultListenAddr string = ":8443"
+
+// Header in which Telegram sends the secret token of the webhook.
+const secretTokenHeader string = "X-Telegram-Bot-Api-Secret-Token"
+
+// Maximum size of the body of a webhook request.
+const defaultMaxBodySize int64 = 1 << 20
+
+// Number of dispatcher workers. Handlers mostly wait for the network, so there are more workers than CPUs.
+const defaultWorkers int = 64
+
+// Maximum number of updates waiting to be handled, all chats included.
+const defaultQueueSize int = 1024
+
+// Maximum size of the files downloaded from the Telegram API, unless the Bot API server runs locally.
+const maxDownloadSize int64 = 20 << 20
 
 // API endpoints
 const answerCallbackQueryEndpoint string = "/answerCallbackQuery"
@@ -11,31 +42,44 @@ const deleteMessageEndpoint string = "/deleteMessage"
 const deleteWebhookEndpoint string = "/deleteWebhook"
 const editMessageReplyMarkupEndpoint string = "/editMessageReplyMarkup"
 const editMessageTextEndpoint string = "/editMessageText"
+const getFileEndpoint string = "/getFile"
+const getMeEndpoint string = "/getMe"
 const getUpdatesEndpoint string = "/getUpdates"
+const getWebhookInfoEndpoint string = "/getWebhookInfo"
 const kickChatMemberEndpoint string = "/kickChatMember"
 const setMyCommandsEndpoint string = "/setMyCommands"
+const sendAnimationEndpoint string = "/sendAnimation"
+const sendAudioEndpoint string = "/sendAudio"
 const sendDiceEndpoint string = "/sendDice"
+const sendDocumentEndpoint string = "/sendDocument"
+const sendMediaGroupEndpoint string = "/sendMediaGroup"
 const sendMessageEndpoint string = "/sendMessage"
+const sendPhotoEndpoint string = "/sendPhoto"
+const sendVideoEndpoint string = "/sendVideo"
+const sendVideoNoteEndpoint string = "/sendVideoNote"
+const sendVoiceEndpoint string = "/sendVoice"
 const setWebhookEndpoint string = "/setWebhook"
 const unbanChatMemberEndpoint string = "/unbanChatMember"
 
+// Networks from which Telegram sends webhook requests, as published in the Telegram documentation.
+// Use them with the WithSourceAllowlist option.
+var TelegramSubnets = []*net.IPNet{
+	mustParseCIDR("149.154.160.0/20"),
+	mustParseCIDR("91.108.4.0/22"),
+}
+
 //
 // Events
 //
 
-// Match commands (i.e. when text starts with the filter but can contain more text)
+// Match commands (i.e. when the text starts with the command filter, with or without a leading slash, followed by arguments)
 var ONCOMMAND = Event{
 	Identifier: "oncommand",
 	Checker: func(toCheck string, filter string) bool {
 
-		var match bool
+		command, ok := ParseCommand(filter)
 
-		if len(toCheck) > 0 && toCheck[0:1] == "/" {
-			match, _ = regexp.MatchString("^"+toCheck+".*", filter)
-		} else {
-			match, _ = regexp.MatchString("^/"+toCheck+".*", filter)
-		}
-		return match
+		return ok && strings.EqualFold(command.Name, commandName(toCheck))
 	},
 }
 
@@ -59,7 +103,6 @@ var ONCALLBACK = Event{
 var ONPAYLOAD = Event{
 	Identifier: "onpayload",
 	Checker: func(toCheck string, filter string) bool {
-		match, _ := regexp.MatchString("^"+toCheck+".*", filter)
-		return match
+		return strings.HasPrefix(filter, toCheck)
 	},
 }
@@ -0,0 +1,191 @@
+package telebot
+
+import (
+	"context"
+	"regexp"
+	"sync"
+)
+
+// Context is passed to handlers. It holds the update being handled and helpers to answer it.
+// It implements context.Context: it is cancelled when the bot gives up waiting for handlers on shutdown,
+// and can be passed to the Ctx variants of the bot methods.
+type Context struct {
+	context.Context
+
+	Bot    *Bot
+	Update *Update
+
+	// Submatches of the regular expression of the handler registered with OnRegex.
+	regexp  *regexp.Regexp
+	matches []string
+
+	// Set when a handler stops the propagation of the update.
+	stopped bool
+
+	mu    sync.Mutex
+	store map[string]interface{}
+}
+
+// Create the context of the update u.
+func newContext(ctx context.Context, b *Bot, u *Update) *Context {
+	return &Context{Context: ctx, Bot: b, Update: u}
+}
+
+// Return the chat the update comes from, or nil if there is none.
+func (c *Context) Chat() *Chat {
+	return updateChat(c.Update)
+}
+
+// Return the user who sent the update, or nil if unknown.
+func (c *Context) Sender() *User {
+	return updateSender(c.Update)
+}
+
+// Return the message of the update: the message received, edited or posted in a channel,
+// or the message of the callback query. Return nil if there is none.
+func (c *Context) Message() *Message {
+	return updateMessage(c.Update)
+}
+
+// Return the command of the message of the update, or nil if the message is not a command.
+func (c *Context) Command() *Command {
+
+	if c.Update.Message == nil || c.Update.Message.Text == "" {
+		return nil
+	}
+
+	command, ok := ParseCommand(c.Update.Message.Text)
+
+	if !ok {
+		return nil
+	}
+
+	return command
+}
+
+// Return the arguments of the command of the message of the update.
+func (c *Context) Args() []string {
+
+	if command := c.Command(); command != nil {
+		return command.Args
+	}
+
+	return nil
+}
+
+// Stop the propagation of the update: the handlers after the current one do not run.
+func (c *Context) StopPropagation() {
+	c.stopped = true
+}
+
+// Return the submatches of the regular expression of a handler registered with OnRegex:
+// the whole match followed by the capture groups.
+func (c *Context) Matches() []string {
+	return c.matches
+}
+
+// Return the submatch of the named capture group name of the regular expression of a handler registered with OnRegex.
+func (c *Context) NamedMatch(name string) string {
+
+	if c.regexp == nil {
+		return ""
+	}
+
+	if i := c.regexp.SubexpIndex(name); i >= 0 && i < len(c.matches) {
+		return c.matches[i]
+	}
+
+	return ""
+}
+
+// Send the message text in the chat of the update.
+// At most one SendMessageOptions can be passed.
+func (c *Context) Send(text string, options ...SendMessageOptions) (*Message, error) {
+
+	chat := c.Chat()
+
+	if chat == nil {
+		return nil, ErrNoChat
+	}
+
+	return c.Bot.SendTextMessageCtx(c, chat.Id, text, firstOptions(options))
+}
+
+// Send the message text in the chat of the update, as a reply to the message of the update.
+// At most one SendMessageOptions can be passed.
+func (c *Context) Reply(text string, options ...SendMessageOptions) (*Message, error) {
+
+	message := c.Message()
+
+	if message == nil {
+		return nil, ErrNoChat
+	}
+
+	replyOptions := firstOptions(options)
+	replyOptions.ReplyToMessageId = message.Id
+	replyOptions.AllowSendingWithoutReply = true
+
+	return c.Bot.SendTextMessageCtx(c, message.Chat.Id, text, replyOptions)
+}
+
+// Edit the text of the message of the update, typically the message of a callback query.
+// At most one SendMessageOptions can be passed.
+func (c *Context) Edit(text string, options ...SendMessageOptions) (*Message, error) {
+
+	message := c.Message()
+
+	if message == nil {
+		return nil, ErrNoChat
+	}
+
+	return c.Bot.EditTextMessageCtx(c, message.Chat.Id, text, message.Id, firstOptions(options))
+}
+
+// Answer the callback query of the update. If text is not empty, it is shown to the user as a notification,
+// or as an alert if showAlert is true.
+func (c *Context) Answer(text string, showAlert bool) (bool, error) {
+
+	if c.Update.CallbackQuery == nil {
+		return false, ErrNoCallbackQuery
+	}
+
+	if text == "" {
+		return c.Bot.AnswerCallbackQueryCtx(c, c.Update.CallbackQuery.Id)
+	}
+
+	return c.Bot.AnswerCallbackQueryNotificationCtx(c, c.Update.CallbackQuery.Id, text, showAlert)
+}
+
+// Store a value in the context, for instance to pass data from a middleware to the handlers.
+func (c *Context) Set(key string, value interface{}) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.store == nil {
+		c.store = make(map[string]interface{})
+	}
+
+	c.store[key] = value
+}
+
+// Get a value stored in the context.
+func (c *Context) Get(key string) (interface{}, bool) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.store[key]
+
+	return value, ok
+}
+
+// Return the first options passed to a variadic helper, or the default options.
+func firstOptions(options []SendMessageOptions) SendMessageOptions {
+
+	if len(options) > 0 {
+		return options[0]
+	}
+
+	return SendMessageOptions{}
+}
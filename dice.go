@@ -1,32 +1,48 @@
 package telebot
 
 import (
+	"context"
 	"math/rand"
 	"net/url"
 	"strconv"
 )
 
 // send a dice
-func (b *Bot) SendDice(chatId int, options SendMessageOptions) (string, error) {
+func (b *Bot) SendDice(chatId int64, options SendMessageOptions) (*Message, error) {
+	return b.SendDiceCtx(context.Background(), chatId, options)
+}
+
+// SendDice with a context controlling the call.
+func (b *Bot) SendDiceCtx(ctx context.Context, chatId int64, options SendMessageOptions) (*Message, error) {
 
-	return b.SendDiceEmoji(chatId, "", options)
+	return b.SendDiceEmojiCtx(ctx, chatId, "", options)
 }
 
 // send a random dice
-func (b *Bot) SendRandomDice(chatId int, options SendMessageOptions) (string, error) {
+func (b *Bot) SendRandomDice(chatId int64, options SendMessageOptions) (*Message, error) {
+	return b.SendRandomDiceCtx(context.Background(), chatId, options)
+}
+
+// SendRandomDice with a context controlling the call.
+func (b *Bot) SendRandomDiceCtx(ctx context.Context, chatId int64, options SendMessageOptions) (*Message, error) {
 
 	emojiList := []string{"🎲", "🎯", "🏀", "⚽", "🎳", "🎰"}
 
 	emoji := emojiList[rand.Intn(len(emojiList))]
 
-	return b.SendDiceEmoji(chatId, emoji, options)
+	return b.SendDiceEmojiCtx(ctx, chatId, emoji, options)
 
 }
 
 // Send a dice Emoji (Supported emojis : “🎲”, “🎯”, “🏀”, “⚽”, “🎳”, or “🎰”. Default is “🎲”. )
-func (b *Bot) SendDiceEmoji(chatId int, emoji string, options SendMessageOptions) (string, error) {
+func (b *Bot) SendDiceEmoji(chatId int64, emoji string, options SendMessageOptions) (*Message, error) {
+	return b.SendDiceEmojiCtx(context.Background(), chatId, emoji, options)
+}
+
+// SendDiceEmoji with a context controlling the call.
+func (b *Bot) SendDiceEmojiCtx(ctx context.Context, chatId int64, emoji string, options SendMessageOptions) (*Message, error) {
 	val := url.Values{
-		"chat_id":                     {strconv.Itoa(chatId)},
+		"chat_id":                     {strconv.FormatInt(chatId, 10)},
 		"disable_notification":        {strconv.FormatBool(options.DisableNotification)},
 		"allow_sending_without_reply": {strconv.FormatBool(options.AllowSendingWithoutReply)},
 	}
@@ -41,5 +57,5 @@ func (b *Bot) SendDiceEmoji(chatId int, emoji string, options SendMessageOptions
 		val["reply_to_message_id"] = []string{strconv.Itoa(options.ReplyToMessageId)}
 	}
 
-	return b.makeAPICall(sendDiceEndpoint, val)
+	return b.makeMessageAPICall(ctx, sendDiceEndpoint, val)
 }
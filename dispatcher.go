@@ -0,0 +1,235 @@
+package telebot
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// DispatchStats reports the activity of the update dispatcher since the bot was started.
+type DispatchStats struct {
+	// Number of updates waiting in the queues.
+	Queued int
+	// Number of updates handled.
+	Processed int64
+	// Number of updates dropped because the queues were full until the reception was cancelled.
+	Dropped int64
+}
+
+// dispatcher processes updates concurrently with a pool of workers.
+// Updates from the same chat are handled one at a time, in the order of reception: each chat has its own queue,
+// and an idle worker takes the next update of a chat which has no update being handled.
+// A slow chat thus only delays its own updates.
+type dispatcher struct {
+	processed int64
+	dropped   int64
+
+	workers sync.WaitGroup
+
+	// Reserved by each update waiting to be handled, to bound the number of updates waiting.
+	slots chan struct{}
+
+	// Guard the queues of the chats.
+	mu sync.Mutex
+	// Updates waiting to be handled, by chat.
+	pending map[int64][]*Update
+	// Chats whose update is being handled.
+	busy map[int64]bool
+	// Chats with updates waiting and no update being handled, in the order they became ready.
+	ready  []int64
+	queued int
+	closed bool
+	// Signalled when a chat becomes ready or the dispatcher is closed.
+	wake *sync.Cond
+
+	// Closed when the dispatcher stops accepting updates, to release the updates waiting for a slot.
+	stopping  chan struct{}
+	closeOnce sync.Once
+}
+
+// Create a dispatcher and start its workers. Up to queueSize updates can wait to be handled, all chats included.
+// ctx is the parent of the contexts passed to handlers.
+func (b *Bot) startDispatcher(ctx context.Context, workers int, queueSize int) *dispatcher {
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	d := &dispatcher{
+		slots:    make(chan struct{}, queueSize),
+		pending:  make(map[int64][]*Update),
+		busy:     make(map[int64]bool),
+		stopping: make(chan struct{}),
+	}
+	d.wake = sync.NewCond(&d.mu)
+
+	for i := 0; i < workers; i++ {
+		d.workers.Add(1)
+		go func() {
+			defer d.workers.Done()
+
+			for {
+				chatId, u, ok := d.next()
+
+				if !ok {
+					return
+				}
+
+				b.dispatchUpdate(ctx, u)
+				atomic.AddInt64(&d.processed, 1)
+
+				d.release(chatId)
+			}
+		}()
+	}
+
+	return d
+}
+
+// Queue the update u in the queue of its chat.
+// Block while the queues are full, and drop the update if ctx is done or the dispatcher is closed first.
+func (d *dispatcher) enqueue(ctx context.Context, u *Update) bool {
+
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		atomic.AddInt64(&d.dropped, 1)
+		return false
+	case <-d.stopping:
+		atomic.AddInt64(&d.dropped, 1)
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		<-d.slots
+		atomic.AddInt64(&d.dropped, 1)
+		return false
+	}
+
+	chatId := updateChatId(u)
+	d.pending[chatId] = append(d.pending[chatId], u)
+	d.queued++
+
+	// The chat becomes ready unless one of its updates is being handled.
+	if !d.busy[chatId] && len(d.pending[chatId]) == 1 {
+		d.ready = append(d.ready, chatId)
+		d.wake.Signal()
+	}
+
+	return true
+}
+
+// Block until a chat is ready and return its next update, marking the chat as busy.
+// The last value is false once the dispatcher is closed and all the updates are handled.
+func (d *dispatcher) next() (int64, *Update, bool) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for len(d.ready) == 0 {
+		if d.closed && d.queued == 0 {
+			return 0, nil, false
+		}
+		d.wake.Wait()
+	}
+
+	chatId := d.ready[0]
+	d.ready = d.ready[1:]
+
+	queue := d.pending[chatId]
+	u := queue[0]
+
+	if len(queue) == 1 {
+		delete(d.pending, chatId)
+	} else {
+		d.pending[chatId] = queue[1:]
+	}
+
+	d.busy[chatId] = true
+	d.queued--
+	<-d.slots
+
+	// The last update was taken: the other workers can exit.
+	if d.closed && d.queued == 0 {
+		d.wake.Broadcast()
+	}
+
+	return chatId, u, true
+}
+
+// Mark the update of the chat chatId as handled. The chat is ready again if it has updates waiting.
+func (d *dispatcher) release(chatId int64) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.busy, chatId)
+
+	if len(d.pending[chatId]) > 0 {
+		d.ready = append(d.ready, chatId)
+		d.wake.Signal()
+	}
+}
+
+// Stop accepting updates. Workers return once the queues are drained.
+func (d *dispatcher) close() {
+
+	d.closeOnce.Do(func() {
+		// Release the updates waiting for a slot.
+		close(d.stopping)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.closed = true
+		d.wake.Broadcast()
+	})
+}
+
+// Return the statistics of the dispatcher.
+func (d *dispatcher) stats() DispatchStats {
+
+	d.mu.Lock()
+	queued := d.queued
+	d.mu.Unlock()
+
+	return DispatchStats{
+		Queued:    queued,
+		Processed: atomic.LoadInt64(&d.processed),
+		Dropped:   atomic.LoadInt64(&d.dropped),
+	}
+}
+
+// Queue an update in the dispatcher of the running bot. Return false if the update was dropped.
+func (b *Bot) enqueueUpdate(ctx context.Context, u *Update) bool {
+
+	b.mu.Lock()
+	d := b.dispatcher
+	b.mu.Unlock()
+
+	if d == nil {
+		return false
+	}
+
+	return d.enqueue(ctx, u)
+}
+
+// Return the statistics of the update dispatcher.
+func (b *Bot) DispatchStats() DispatchStats {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.dispatcher == nil {
+		return DispatchStats{}
+	}
+
+	return b.dispatcher.stats()
+}
@@ -0,0 +1,179 @@
+package telebot
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Create a bot whose only handler is handler.
+func newDispatchBot(handler HandlerFunc) *Bot {
+
+	b := &Bot{}
+	b.handlers = []*handlerEntry{{match: func(c *Context) bool { return true }, handler: handler}}
+
+	return b
+}
+
+// Create an update from the chat chatId.
+func chatUpdate(updateId int, chatId int64) *Update {
+	return &Update{UpdateId: updateId, Message: &Message{Chat: Chat{Id: chatId}}}
+}
+
+func TestDispatcherPreservesChatOrder(t *testing.T) {
+
+	var mu sync.Mutex
+	received := map[int64][]int{}
+
+	b := newDispatchBot(func(c *Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		chatId := c.Update.Message.Chat.Id
+		received[chatId] = append(received[chatId], c.Update.UpdateId)
+		return nil
+	})
+
+	d := b.startDispatcher(context.Background(), 4, 2)
+
+	want := map[int64][]int{}
+	for i := 0; i < 100; i++ {
+		chatId := int64(i%3 + 1)
+		want[chatId] = append(want[chatId], i)
+
+		if !d.enqueue(context.Background(), chatUpdate(i, chatId)) {
+			t.Fatalf("enqueue(%d) dropped the update", i)
+		}
+	}
+
+	d.close()
+	d.workers.Wait()
+
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("updates received by chat = %v, want %v", received, want)
+	}
+
+	if stats := d.stats(); stats.Processed != 100 || stats.Dropped != 0 || stats.Queued != 0 {
+		t.Errorf("stats() = %+v, want 100 processed", stats)
+	}
+}
+
+func TestDispatcherCloseReleasesBlockedEnqueue(t *testing.T) {
+
+	handlersCtx, cancelHandlers := context.WithCancel(context.Background())
+	defer cancelHandlers()
+
+	started := make(chan struct{}, 2)
+
+	// The handler blocks its worker until the handlers are cancelled.
+	b := newDispatchBot(func(c *Context) error {
+		started <- struct{}{}
+		<-c.Done()
+		return nil
+	})
+
+	d := b.startDispatcher(handlersCtx, 1, 1)
+
+	if !d.enqueue(context.Background(), chatUpdate(1, 1)) {
+		t.Fatal("enqueue() dropped the first update")
+	}
+	<-started
+
+	// The worker is busy and the second update fills the queue: the third update blocks.
+	if !d.enqueue(context.Background(), chatUpdate(2, 2)) {
+		t.Fatal("enqueue() dropped the second update")
+	}
+
+	enqueued := make(chan bool)
+	go func() {
+		enqueued <- d.enqueue(context.Background(), chatUpdate(3, 3))
+	}()
+
+	// Close must not wait for the blocked update.
+	closed := make(chan struct{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		d.close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("close() blocked on an update waiting for a full queue")
+	}
+
+	select {
+	case ok := <-enqueued:
+		if ok {
+			t.Error("enqueue() queued an update after close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("enqueue() still blocked after close")
+	}
+
+	// The in-flight handler outlives the shutdown timeout, then is cancelled.
+	if waitTimeout(&d.workers, 50*time.Millisecond) {
+		t.Error("waitTimeout() = true while a handler is running")
+	}
+
+	// The update queued before close is still handled.
+	cancelHandlers()
+	d.workers.Wait()
+
+	if stats := d.stats(); stats.Processed != 2 || stats.Dropped != 1 || stats.Queued != 0 {
+		t.Errorf("stats() = %+v, want 2 processed and 1 dropped", stats)
+	}
+
+	// Updates received after close are dropped.
+	if d.enqueue(context.Background(), chatUpdate(4, 1)) {
+		t.Error("enqueue() after close = true, want false")
+	}
+}
+
+func TestDispatcherSlowChatDoesNotBlockOtherChats(t *testing.T) {
+
+	release := make(chan struct{})
+	handled := make(chan int64, 10)
+
+	// Updates from chat 1 block until released.
+	b := newDispatchBot(func(c *Context) error {
+		chatId := c.Update.Message.Chat.Id
+		if chatId == 1 {
+			<-release
+		}
+		handled <- chatId
+		return nil
+	})
+
+	d := b.startDispatcher(context.Background(), 2, 10)
+	defer func() {
+		close(release)
+		d.close()
+		d.workers.Wait()
+	}()
+
+	// Chats 1 and 3 would share a worker if chats were assigned to workers by their id.
+	for i, chatId := range []int64{1, 1, 1, 3, 3, 5} {
+		if !d.enqueue(context.Background(), chatUpdate(i, chatId)) {
+			t.Fatalf("enqueue(%d) dropped the update", i)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case chatId := <-handled:
+			if chatId == 1 {
+				t.Fatal("an update of the blocked chat was handled")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("updates of other chats are blocked by a slow chat")
+		}
+	}
+
+	if stats := d.stats(); stats.Queued != 2 {
+		t.Errorf("stats().Queued = %d, want the 2 updates of the blocked chat", stats.Queued)
+	}
+}
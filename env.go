@@ -0,0 +1,163 @@
+package telebot
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by OptionsFromEnv and CreateBotFromEnv.
+const (
+	envToken              = "TELEBOT_TOKEN"
+	envMode               = "TELEBOT_MODE"
+	envBaseUrl            = "TELEBOT_BASE_URL"
+	envLocalAPIServer     = "TELEBOT_LOCAL_API_SERVER"
+	envWebhookUrl         = "TELEBOT_WEBHOOK_URL"
+	envWebhookIpAddress   = "TELEBOT_WEBHOOK_IP_ADDRESS"
+	envSslCertificate     = "TELEBOT_SSL_CERTIFICATE"
+	envSslPrivkey         = "TELEBOT_SSL_PRIVKEY"
+	envWebhookCertificate = "TELEBOT_WEBHOOK_CERTIFICATE"
+	envListenAddr         = "TELEBOT_LISTEN_ADDR"
+	envPlainHTTP          = "TELEBOT_PLAIN_HTTP"
+	envSecretToken        = "TELEBOT_SECRET_TOKEN"
+	envMaxConnections     = "TELEBOT_MAX_CONNECTIONS"
+	envDropPendingUpdates = "TELEBOT_DROP_PENDING_UPDATES"
+	envAllowedUpdates     = "TELEBOT_ALLOWED_UPDATES"
+	envPollTimeout        = "TELEBOT_POLL_TIMEOUT"
+	envPollLimit          = "TELEBOT_POLL_LIMIT"
+	envWorkers            = "TELEBOT_WORKERS"
+	envQueueSize          = "TELEBOT_QUEUE_SIZE"
+	envShutdownTimeout    = "TELEBOT_SHUTDOWN_TIMEOUT"
+)
+
+// Create a bot configured with environment variables. The API token is read from TELEBOT_TOKEN.
+// The options passed as parameters are applied after the ones read from the environment.
+func CreateBotFromEnv(opts ...Option) (*Bot, error) {
+
+	envOpts, err := OptionsFromEnv()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateBot(os.Getenv(envToken), append(envOpts, opts...)...)
+}
+
+// Read the options of a bot from environment variables:
+//
+//	TELEBOT_MODE                  "polling" (default) or "webhook"
+//	TELEBOT_BASE_URL              base URL of the Telegram API
+//	TELEBOT_LOCAL_API_SERVER      the Bot API server runs with --local (boolean)
+//	TELEBOT_WEBHOOK_URL           public URL of the webhook
+//	TELEBOT_WEBHOOK_IP_ADDRESS    IP address used by Telegram to reach the webhook
+//	TELEBOT_SSL_CERTIFICATE       path to the certificate of the webhook server
+//	TELEBOT_SSL_PRIVKEY           path to the private key of the webhook server
+//	TELEBOT_WEBHOOK_CERTIFICATE   path to the public certificate uploaded to Telegram
+//	TELEBOT_LISTEN_ADDR           listen address of the webhook server
+//	TELEBOT_PLAIN_HTTP            serve the webhook over plain HTTP (boolean)
+//	TELEBOT_SECRET_TOKEN          secret token of the webhook
+//	TELEBOT_MAX_CONNECTIONS       maximum number of connections to the webhook
+//	TELEBOT_DROP_PENDING_UPDATES  drop pending updates on start (boolean)
+//	TELEBOT_ALLOWED_UPDATES       comma separated types of updates received
+//	TELEBOT_POLL_TIMEOUT          long polling timeout (duration such as "30s")
+//	TELEBOT_POLL_LIMIT            maximum number of updates per poll
+//	TELEBOT_WORKERS               number of workers handling updates
+//	TELEBOT_QUEUE_SIZE            maximum number of updates waiting to be handled
+//	TELEBOT_SHUTDOWN_TIMEOUT      timeout of the graceful shutdown (duration)
+//
+// Unset variables are ignored. A *ConfigError is returned if some values cannot be parsed.
+func OptionsFromEnv() ([]Option, error) {
+
+	var opts []Option
+	var problems []string
+
+	// Helpers parsing the typed variables.
+	parseInt := func(name string, apply func(int) Option) {
+		if value, ok := os.LookupEnv(name); ok {
+			if n, err := strconv.Atoi(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s is not an integer: %q", name, value))
+			} else {
+				opts = append(opts, apply(n))
+			}
+		}
+	}
+	parseBool := func(name string, apply func() Option) {
+		if value, ok := os.LookupEnv(name); ok {
+			if enabled, err := strconv.ParseBool(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s is not a boolean: %q", name, value))
+			} else if enabled {
+				opts = append(opts, apply())
+			}
+		}
+	}
+	parseDuration := func(name string, apply func(time.Duration) Option) {
+		if value, ok := os.LookupEnv(name); ok {
+			if d, err := time.ParseDuration(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s is not a duration: %q", name, value))
+			} else {
+				opts = append(opts, apply(d))
+			}
+		}
+	}
+	parseString := func(name string, apply func(string) Option) {
+		if value, ok := os.LookupEnv(name); ok {
+			opts = append(opts, apply(value))
+		}
+	}
+
+	// Mode and webhook settings.
+	switch mode := os.Getenv(envMode); mode {
+	case "", PollingMode.String():
+		opts = append(opts, WithPolling())
+	case WebhookMode.String():
+		opts = append(opts, WithWebhook(WebhookConfig{
+			Url:            os.Getenv(envWebhookUrl),
+			IpAddress:      os.Getenv(envWebhookIpAddress),
+			SslCertificate: os.Getenv(envSslCertificate),
+			SslPrivkey:     os.Getenv(envSslPrivkey),
+		}))
+	default:
+		problems = append(problems, fmt.Sprintf("%s must be %q or %q: %q", envMode, PollingMode, WebhookMode, mode))
+	}
+
+	// Webhook settings in polling mode are most likely a mistake.
+	if os.Getenv(envMode) != WebhookMode.String() {
+		for _, name := range []string{envWebhookUrl, envWebhookIpAddress, envSslCertificate, envSslPrivkey} {
+			if _, ok := os.LookupEnv(name); ok {
+				problems = append(problems, fmt.Sprintf("%s is set but %s is not %q", name, envMode, WebhookMode))
+			}
+		}
+	}
+
+	parseString(envBaseUrl, WithBaseURL)
+	parseBool(envLocalAPIServer, WithLocalAPIServer)
+	parseString(envWebhookCertificate, WithWebhookCertificate)
+	parseString(envListenAddr, WithListenAddr)
+	parseBool(envPlainHTTP, WithPlainHTTP)
+	parseString(envSecretToken, WithSecretToken)
+	parseInt(envMaxConnections, WithMaxConnections)
+	parseBool(envDropPendingUpdates, WithDropPendingUpdates)
+	parseDuration(envPollTimeout, WithPollTimeout)
+	parseInt(envPollLimit, WithPollLimit)
+	parseInt(envWorkers, WithWorkers)
+	parseInt(envQueueSize, WithQueueSize)
+	parseDuration(envShutdownTimeout, WithShutdownTimeout)
+
+	if value, ok := os.LookupEnv(envAllowedUpdates); ok {
+		var updateTypes []string
+		for _, updateType := range strings.Split(value, ",") {
+			if updateType = strings.TrimSpace(updateType); updateType != "" {
+				updateTypes = append(updateTypes, updateType)
+			}
+		}
+		opts = append(opts, WithAllowedUpdates(updateTypes...))
+	}
+
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+
+	return opts, nil
+}
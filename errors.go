@@ -0,0 +1,57 @@
+package telebot
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIError is returned when the Telegram API answers a request with ok set to false.
+type APIError struct {
+	ErrorCode   int
+	Description string
+	Parameters  *ResponseParameters
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram api error %d: %s", e.ErrorCode, e.Description)
+}
+
+// Errors returned by the bot lifecycle methods.
+var (
+	ErrAlreadyStarted  = errors.New("telebot: bot already started")
+	ErrNotStarted      = errors.New("telebot: bot not started")
+	ErrShutdownTimeout = errors.New("telebot: shutdown timeout reached before all handlers returned")
+)
+
+// Errors returned by the Context helpers when the update has nothing to answer to.
+var (
+	ErrNoChat          = errors.New("telebot: the update has no chat")
+	ErrNoCallbackQuery = errors.New("telebot: the update has no callback query")
+)
+
+// Error returned by SendMediaGroup when the items cannot be sent as an album.
+var ErrInvalidMediaGroup = errors.New("telebot: a media group has 2 to 10 items, and documents and audios can only be grouped with items of the same type")
+
+// Error returned when downloading a file larger than the 20 MB allowed by the Telegram API.
+var ErrFileTooLarge = errors.New("telebot: files larger than 20 MB cannot be downloaded from the Telegram API")
+
+// Error closing the body of an upload when the request ends before the body is sent.
+var errUploadAborted = errors.New("telebot: upload aborted")
+
+// Error returned when a file read by a failed attempt cannot be read again to retry the upload.
+// It wraps the error of the failed attempt.
+type rewindError struct {
+	err     error
+	lastErr error
+}
+
+// Error implements the error interface.
+func (e *rewindError) Error() string {
+	return fmt.Sprintf("%s: %s", e.err.Error(), e.lastErr.Error())
+}
+
+// Unwrap returns the error of the failed attempt.
+func (e *rewindError) Unwrap() error {
+	return e.lastErr
+}
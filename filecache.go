@@ -0,0 +1,250 @@
+package telebot
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileCache stores the file_id of the files uploaded by the bot, so that identical files are sent again
+// with their file_id instead of being uploaded. Keys are content hashes or the keys set with InputFile.WithCacheKey.
+// Implementations must be safe for concurrent use.
+type FileCache interface {
+	// Return the file_id stored for key, if any.
+	Get(key string) (string, bool)
+	// Store the file_id of key.
+	Set(key string, fileId string) error
+	// Remove key, when its file_id is rejected by Telegram.
+	Delete(key string) error
+}
+
+// MemoryFileCache is a FileCache keeping the file_ids in memory. They are lost when the bot restarts.
+type MemoryFileCache struct {
+	mu      sync.RWMutex
+	fileIds map[string]string
+}
+
+// Create an empty MemoryFileCache.
+func NewMemoryFileCache() *MemoryFileCache {
+	return &MemoryFileCache{fileIds: make(map[string]string)}
+}
+
+// Get implements the FileCache interface.
+func (c *MemoryFileCache) Get(key string) (string, bool) {
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	fileId, ok := c.fileIds[key]
+
+	return fileId, ok
+}
+
+// Set implements the FileCache interface.
+func (c *MemoryFileCache) Set(key string, fileId string) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fileIds[key] = fileId
+
+	return nil
+}
+
+// Delete implements the FileCache interface.
+func (c *MemoryFileCache) Delete(key string) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.fileIds, key)
+
+	return nil
+}
+
+// DiskFileCache is a FileCache keeping the file_ids in memory and in a JSON file, so that they survive restarts.
+type DiskFileCache struct {
+	path   string
+	memory *MemoryFileCache
+
+	// Serializes the writes of the file.
+	writeMu sync.Mutex
+}
+
+// Create a DiskFileCache stored in the JSON file at path, loading the file_ids already stored in it.
+func NewDiskFileCache(path string) (*DiskFileCache, error) {
+
+	c := &DiskFileCache{path: path, memory: NewMemoryFileCache()}
+
+	data, err := os.ReadFile(path)
+
+	// The file is created on the first upload.
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &c.memory.fileIds); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Get implements the FileCache interface.
+func (c *DiskFileCache) Get(key string) (string, bool) {
+	return c.memory.Get(key)
+}
+
+// Set implements the FileCache interface.
+func (c *DiskFileCache) Set(key string, fileId string) error {
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.memory.Set(key, fileId)
+
+	return c.save()
+}
+
+// Delete implements the FileCache interface.
+func (c *DiskFileCache) Delete(key string) error {
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.memory.Delete(key)
+
+	return c.save()
+}
+
+// Write the file_ids to the file. The file is replaced atomically, so that a crash never leaves it truncated.
+func (c *DiskFileCache) save() error {
+
+	c.memory.mu.RLock()
+	data, err := json.Marshal(c.memory.fileIds)
+	c.memory.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// Return the key of file sent as mediaType ("photo", "document"...) in the file cache of the bot,
+// or "" if the file cannot be cached. A file_id can only be sent again as the type it was sent as,
+// so the type is part of the key.
+func (b *Bot) fileCacheKey(mediaType string, file *InputFile) string {
+
+	if b.fileCache == nil || !file.isUpload() {
+		return ""
+	}
+
+	if file.cacheKey != "" {
+		return mediaType + ":" + file.cacheKey
+	}
+
+	// Readers which cannot be read twice are only cached with a key set by the caller.
+	hash, err := file.hash()
+
+	if err != nil {
+		return ""
+	}
+
+	return mediaType + ":" + hash
+}
+
+// Return the file_id stored for key in the file cache of the bot.
+func (b *Bot) cachedFileId(key string) (string, bool) {
+
+	if key == "" {
+		return "", false
+	}
+
+	return b.fileCache.Get(key)
+}
+
+// Store the file_id of key in the file cache of the bot.
+func (b *Bot) storeFileId(key string, fileId string) {
+
+	if key == "" || fileId == "" {
+		return
+	}
+
+	if err := b.fileCache.Set(key, fileId); err != nil {
+		log.Printf("Error storing file_id in the file cache: %s", err.Error())
+	}
+}
+
+// Remove key from the file cache of the bot.
+func (b *Bot) forgetFileId(key string) {
+
+	if err := b.fileCache.Delete(key); err != nil {
+		log.Printf("Error removing file_id from the file cache: %s", err.Error())
+	}
+}
+
+// Return the file_id of the file sent in the parameter field of a message, "" if there is none.
+func messageFileId(message *Message, field string) string {
+
+	switch {
+	case field == "photo" && len(message.Photo) > 0:
+		// Photos come in several sizes, the largest being the last one.
+		return message.Photo[len(message.Photo)-1].FileId
+	case field == "document" && message.Document != nil:
+		return message.Document.FileId
+	case field == "audio" && message.Audio != nil:
+		return message.Audio.FileId
+	case field == "video" && message.Video != nil:
+		return message.Video.FileId
+	case field == "voice" && message.Voice != nil:
+		return message.Voice.FileId
+	case field == "video_note" && message.VideoNote != nil:
+		return message.VideoNote.FileId
+	case field == "animation" && message.Animation != nil:
+		return message.Animation.FileId
+	}
+
+	return ""
+}
+
+// Check if err reports a file_id rejected by Telegram, for instance because it expired
+// or because it was stored for another type of file.
+func isInvalidFileId(err error) bool {
+
+	var apiErr *APIError
+
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != http.StatusBadRequest {
+		return false
+	}
+
+	description := strings.ToLower(apiErr.Description)
+
+	return strings.Contains(description, "file identifier") || strings.Contains(description, "type of file mismatch")
+}
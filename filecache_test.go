@@ -0,0 +1,132 @@
+package telebot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFileCacheKeyIncludesMediaType(t *testing.T) {
+
+	b := &Bot{fileCache: NewMemoryFileCache()}
+
+	content := []byte("same bytes")
+	file := FileFromReader("file", bytes.NewReader(content))
+
+	photo := b.fileCacheKey("photo", &file)
+	document := b.fileCacheKey("document", &file)
+
+	if photo == "" || document == "" {
+		t.Fatalf("fileCacheKey() returned an empty key: %q, %q", photo, document)
+	}
+
+	if photo == document {
+		t.Errorf("fileCacheKey() = %q for both photo and document", photo)
+	}
+
+	keyed := file.WithCacheKey("key")
+	if got := b.fileCacheKey("photo", &keyed); got != "photo:key" {
+		t.Errorf("fileCacheKey() = %q, want %q", got, "photo:key")
+	}
+}
+
+func TestIsInvalidFileId(t *testing.T) {
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"wrong identifier", &APIError{ErrorCode: 400, Description: "Bad Request: wrong file identifier/HTTP URL specified"}, true},
+		{"type mismatch", &APIError{ErrorCode: 400, Description: "Bad Request: type of file mismatch"}, true},
+		{"other bad request", &APIError{ErrorCode: 400, Description: "Bad Request: chat not found"}, false},
+		{"server error", &APIError{ErrorCode: 500, Description: "file identifier"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isInvalidFileId(test.err); got != test.want {
+				t.Errorf("isInvalidFileId(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSendMediaGroupUploadsAgainAfterInvalidFileId(t *testing.T) {
+
+	content := "content of the second file"
+
+	var mu sync.Mutex
+	calls := 0
+	var uploaded []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm() error = %v", err)
+		}
+
+		uploaded = nil
+		for _, headers := range r.MultipartForm.File {
+			for _, header := range headers {
+				file, _ := header.Open()
+				data, _ := io.ReadAll(file)
+				file.Close()
+				uploaded = append(uploaded, string(data))
+			}
+		}
+
+		// Reject the cached file_id of the first attempt.
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": 400, "description": "Bad Request: wrong file identifier/HTTP URL specified"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": []Message{{}, {}}})
+	}))
+	defer server.Close()
+
+	cache := NewMemoryFileCache()
+	b, err := CreateBot("token", WithBaseURL(server.URL), WithFileCache(cache), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}), WithRateLimits(RateLimits{}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := FileFromReader("first", strings.NewReader("content of the first file"))
+	cache.Set(b.fileCacheKey("document", &first), "expired")
+
+	media := []InputMedia{
+		{Type: "document", Media: first},
+		{Type: "document", Media: FileFromReader("second", strings.NewReader(content))},
+	}
+
+	if _, err := b.SendMediaGroup(1, media, SendMessageOptions{}); err != nil {
+		t.Fatalf("SendMediaGroup() error = %v", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("sendMediaGroup called %d times, want 2", calls)
+	}
+
+	for _, data := range uploaded {
+		if data == "" {
+			t.Errorf("a file was uploaded empty on the second attempt: %q", uploaded)
+		}
+	}
+
+	if len(uploaded) != 2 {
+		t.Errorf("%d files uploaded on the second attempt, want 2", len(uploaded))
+	}
+}
@@ -0,0 +1,143 @@
+package telebot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// Get the file fileId, to download it. Files larger than 20 MB cannot be downloaded, unless the bot uses
+// a local Bot API server.
+func (b *Bot) GetFile(fileId string) (*File, error) {
+	return b.GetFileCtx(context.Background(), fileId)
+}
+
+// GetFile with a context controlling the call.
+func (b *Bot) GetFileCtx(ctx context.Context, fileId string) (*File, error) {
+
+	val := url.Values{
+		"file_id": {fileId},
+	}
+
+	var file File
+
+	if err := b.makeAPICall(ctx, getFileEndpoint, val, &file); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// Return the URL from which file is downloaded. With a local Bot API server, it is a file:// URL
+// when the file path returned by getFile is an absolute path.
+// The URL contains the API token of the bot: do not share it.
+func (b *Bot) FileURL(file *File) string {
+
+	if b.isLocalFile(file) {
+		return (&url.URL{Scheme: "file", Path: file.FilePath}).String()
+	}
+
+	return b.baseUrl + "/file/bot" + b.apiToken + "/" + file.FilePath
+}
+
+// Download the file fileId and write its content to w. The content is streamed to w.
+// Downloads are not retried, since w may have received a part of the content.
+// ErrFileTooLarge is returned once 20 MB were written to w if Telegram did not report the size of the file.
+func (b *Bot) DownloadFile(fileId string, w io.Writer) error {
+	return b.DownloadFileCtx(context.Background(), fileId, w)
+}
+
+// DownloadFile with a context controlling the call.
+func (b *Bot) DownloadFileCtx(ctx context.Context, fileId string, w io.Writer) error {
+
+	file, err := b.GetFileCtx(ctx, fileId)
+
+	if err != nil {
+		return err
+	}
+
+	if !b.localAPIServer && file.FileSize > maxDownloadSize {
+		return ErrFileTooLarge
+	}
+
+	// A local Bot API server stores the files on the local filesystem.
+	if b.isLocalFile(file) {
+		return copyLocalFile(ctx, file.FilePath, w)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, b.FileURL(file), nil)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := b.httpClient.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		if err := decodeAPIResponse(response, nil); err != nil {
+			return err
+		}
+
+		return &APIError{ErrorCode: response.StatusCode, Description: response.Status}
+	}
+
+	if b.localAPIServer {
+		_, err = io.Copy(w, response.Body)
+		return err
+	}
+
+	// The size reported by Telegram may be missing: stop reading past the limit.
+	written, err := io.Copy(w, io.LimitReader(response.Body, maxDownloadSize+1))
+
+	if err == nil && written > maxDownloadSize {
+		return ErrFileTooLarge
+	}
+
+	return err
+}
+
+// Check if file is stored on the local filesystem by a local Bot API server.
+func (b *Bot) isLocalFile(file *File) bool {
+	return b.localAPIServer && filepath.IsAbs(file.FilePath)
+}
+
+// Copy the content of the local file at path to w, until ctx is done.
+func copyLocalFile(ctx context.Context, path string, w io.Writer) error {
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(w, contextReader{ctx: ctx, reader: file})
+
+	return err
+}
+
+// contextReader stops reading from reader once ctx is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+// Read implements the io.Reader interface.
+func (r contextReader) Read(p []byte) (int, error) {
+
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.reader.Read(p)
+}
@@ -0,0 +1,94 @@
+package telebot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadFileSize(t *testing.T) {
+
+	// Sizes of the files by file_id. Telegram does not report the size of the files.
+	sizes := map[string]int64{
+		"small": 1024,
+		"limit": maxDownloadSize,
+		"large": maxDownloadSize + 1,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if strings.HasSuffix(r.URL.Path, "/getFile") {
+			fileId := r.FormValue("file_id")
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": File{FileId: fileId, FilePath: "documents/" + fileId}})
+			return
+		}
+
+		io.CopyN(w, zeroReader{}, sizes[filepath.Base(r.URL.Path)])
+	}))
+	defer server.Close()
+
+	b, err := CreateBot("token", WithBaseURL(server.URL))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fileId string
+		err    error
+	}{
+		{"small", nil},
+		{"limit", nil},
+		{"large", ErrFileTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fileId, func(t *testing.T) {
+			if err := b.DownloadFile(test.fileId, ioutil.Discard); err != test.err {
+				t.Errorf("DownloadFile(%s) error = %v, want %v", test.fileId, err, test.err)
+			}
+		})
+	}
+}
+
+func TestCopyLocalFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "file")
+
+	if err := os.WriteFile(path, []byte("content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+
+	if err := copyLocalFile(context.Background(), path, &out); err != nil || out.String() != "content" {
+		t.Errorf("copyLocalFile() = %q, %v, want %q", out.String(), err, "content")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := copyLocalFile(ctx, path, ioutil.Discard); err != context.Canceled {
+		t.Errorf("copyLocalFile() with a cancelled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// Reader of an endless stream of zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
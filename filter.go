@@ -0,0 +1,102 @@
+package telebot
+
+// Filter is a predicate on updates, used with OnFunc and Only.
+type Filter func(u *Update) bool
+
+// Match updates matching all the filters.
+func And(filters ...Filter) Filter {
+	return func(u *Update) bool {
+		for _, filter := range filters {
+			if !filter(u) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Match updates matching at least one of the filters.
+func Or(filters ...Filter) Filter {
+	return func(u *Update) bool {
+		for _, filter := range filters {
+			if filter(u) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Match updates not matching filter.
+func Not(filter Filter) Filter {
+	return func(u *Update) bool {
+		return !filter(u)
+	}
+}
+
+// Match updates from chats of the given types ("private", "group", "supergroup" or "channel").
+func ChatType(chatTypes ...string) Filter {
+	return func(u *Update) bool {
+		chat := updateChat(u)
+
+		if chat == nil {
+			return false
+		}
+
+		for _, chatType := range chatTypes {
+			if chat.Type == chatType {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Match updates sent by the users userIds.
+func FromUser(userIds ...int64) Filter {
+	return func(u *Update) bool {
+		senderId := updateSenderId(u)
+
+		for _, id := range userIds {
+			if id == senderId {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Match updates whose message text or caption has an entity of one of the given types ("mention", "url", "bot_command"...).
+func HasEntity(entityTypes ...string) Filter {
+	return func(u *Update) bool {
+		message := updateMessage(u)
+
+		if message == nil {
+			return false
+		}
+
+		for _, entities := range [][]MessageEntity{message.Entities, message.CaptionEntities} {
+			for _, entity := range entities {
+				for _, entityType := range entityTypes {
+					if entity.Type == entityType {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+}
+
+// Only run handlers for updates matching filter. Use it with Bot.Use or Bot.Group to filter any event type.
+func Only(filter Filter) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			if !filter(c.Update) {
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
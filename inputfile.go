@@ -0,0 +1,266 @@
+package telebot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// InputFile is a file to send: a local file or a reader uploaded with the request,
+// a URL downloaded by Telegram, or the file_id of a file already stored on the Telegram servers.
+type InputFile struct {
+	path   string
+	reader io.Reader
+	name   string
+	url    string
+	fileId string
+
+	// Key of the file in the file cache of the bot.
+	cacheKey string
+
+	// Position of a seekable reader before the first upload, to upload it again when a call is retried.
+	offset int64
+	read   bool
+}
+
+// Create an InputFile uploading the local file at path.
+func FileFromPath(path string) InputFile {
+	return InputFile{path: path, name: filepath.Base(path)}
+}
+
+// Create an InputFile uploading the content of reader with the file name name.
+// A call failing after reader was read is only retried if reader implements io.Seeker.
+func FileFromReader(name string, reader io.Reader) InputFile {
+	return InputFile{reader: reader, name: name}
+}
+
+// Create an InputFile downloaded by Telegram from url.
+func FileFromURL(url string) InputFile {
+	return InputFile{url: url}
+}
+
+// Create an InputFile referencing a file already stored on the Telegram servers.
+func FileFromId(fileId string) InputFile {
+	return InputFile{fileId: fileId}
+}
+
+// Return a copy of the file stored with key in the file cache of the bot, instead of the hash of its content.
+// It allows caching readers which cannot be read twice, and avoids hashing large files.
+func (f InputFile) WithCacheKey(key string) InputFile {
+	f.cacheKey = key
+	return f
+}
+
+// Check if the file is uploaded with the request, in a multipart/form-data body.
+func (f *InputFile) isUpload() bool {
+	return f.path != "" || f.reader != nil
+}
+
+// Return the value of the parameter of a file which is not uploaded: its URL or its file_id.
+func (f *InputFile) value() string {
+
+	if f.url != "" {
+		return f.url
+	}
+
+	return f.fileId
+}
+
+// Prepare the file to be uploaded again when a call is retried.
+func (f *InputFile) rewind() error {
+
+	if f.reader == nil {
+		return nil
+	}
+
+	seeker, ok := f.reader.(io.Seeker)
+
+	if !f.read {
+		f.read = true
+
+		// Remember where the content starts.
+		if ok {
+			offset, err := seeker.Seek(0, io.SeekCurrent)
+			f.offset = offset
+			return err
+		}
+
+		return nil
+	}
+
+	if !ok {
+		return fmt.Errorf("telebot: %s was already read and cannot be uploaded again", f.name)
+	}
+
+	_, err := seeker.Seek(f.offset, io.SeekStart)
+
+	return err
+}
+
+// Copy the content of the file in the field of a multipart body. counter, if not nil, counts the bytes copied.
+func (f *InputFile) writeMultipart(writer *multipart.Writer, field string, counter *progressCounter) error {
+
+	reader := f.reader
+
+	if f.path != "" {
+		file, err := os.Open(f.path)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		reader = file
+	}
+
+	part, err := writer.CreateFormFile(field, f.name)
+
+	if err != nil {
+		return err
+	}
+
+	if counter != nil {
+		part = io.MultiWriter(part, counter)
+	}
+
+	_, err = io.Copy(part, reader)
+
+	return err
+}
+
+// Return the number of bytes left to upload from the file, or -1 if unknown.
+func (f *InputFile) size() int64 {
+
+	if f.path != "" {
+		info, err := os.Stat(f.path)
+
+		if err != nil {
+			return -1
+		}
+
+		return info.Size()
+	}
+
+	if seeker, ok := f.reader.(io.Seeker); ok {
+		current, err := seeker.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return -1
+		}
+
+		end, err := seeker.Seek(0, io.SeekEnd)
+
+		if err != nil {
+			return -1
+		}
+
+		if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+
+		return end - current
+	}
+
+	return -1
+}
+
+// Return the SHA-256 hash of the content of the file. Readers are read from their current position,
+// and an error is returned if they cannot be read again.
+func (f *InputFile) hash() (string, error) {
+
+	hash := sha256.New()
+
+	switch {
+	case f.path != "":
+		file, err := os.Open(f.path)
+
+		if err != nil {
+			return "", err
+		}
+
+		defer file.Close()
+
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+	default:
+		seeker, ok := f.reader.(io.Seeker)
+
+		if !ok {
+			return "", fmt.Errorf("telebot: %s cannot be read twice", f.name)
+		}
+
+		current, err := seeker.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := io.Copy(hash, f.reader); err != nil {
+			return "", err
+		}
+
+		if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Return the number of bytes of the files to upload, or -1 if unknown.
+func uploadSize(files map[string]*InputFile) int64 {
+
+	var total int64
+
+	for _, file := range files {
+		size := file.size()
+
+		if size < 0 {
+			return -1
+		}
+
+		total += size
+	}
+
+	return total
+}
+
+// ProgressFunc reports the progress of an upload: sent bytes of the files were sent out of total, or -1 if the
+// size of the files is unknown. It is called from the goroutine writing the request body.
+type ProgressFunc func(sent int64, total int64)
+
+// Writer counting the bytes of the files uploaded and reporting them to a ProgressFunc.
+type progressCounter struct {
+	progress ProgressFunc
+	total    int64
+	sent     int64
+}
+
+// Write implements the io.Writer interface.
+func (c *progressCounter) Write(p []byte) (int, error) {
+
+	c.sent += int64(len(p))
+	c.progress(c.sent, c.total)
+
+	return len(p), nil
+}
+
+// Add the file to the parameters of a call: uploaded files are added to files, other files are referenced in v.
+func addInputFile(v url.Values, files map[string]*InputFile, field string, file *InputFile) {
+
+	// The parameter may hold the file_id of a previous attempt.
+	if file.isUpload() {
+		files[field] = file
+		delete(v, field)
+		return
+	}
+
+	v[field] = []string{file.value()}
+}
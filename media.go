@@ -0,0 +1,407 @@
+package telebot
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// Send the photo in the chat chatId.
+func (b *Bot) SendPhoto(chatId int64, photo InputFile, options SendMediaOptions) (*Message, error) {
+	return b.SendPhotoCtx(context.Background(), chatId, photo, options)
+}
+
+// SendPhoto with a context controlling the call.
+func (b *Bot) SendPhotoCtx(ctx context.Context, chatId int64, photo InputFile, options SendMediaOptions) (*Message, error) {
+	return b.sendMedia(ctx, sendPhotoEndpoint, chatId, "photo", &photo, options, url.Values{})
+}
+
+// Send the document in the chat chatId.
+func (b *Bot) SendDocument(chatId int64, document InputFile, options SendMediaOptions) (*Message, error) {
+	return b.SendDocumentCtx(context.Background(), chatId, document, options)
+}
+
+// SendDocument with a context controlling the call.
+func (b *Bot) SendDocumentCtx(ctx context.Context, chatId int64, document InputFile, options SendMediaOptions) (*Message, error) {
+
+	val := url.Values{}
+
+	if options.DisableContentTypeDetection {
+		val["disable_content_type_detection"] = []string{"true"}
+	}
+
+	return b.sendMedia(ctx, sendDocumentEndpoint, chatId, "document", &document, options, val)
+}
+
+// Send the audio in the chat chatId, to be displayed in the music player.
+func (b *Bot) SendAudio(chatId int64, audio InputFile, options SendMediaOptions) (*Message, error) {
+	return b.SendAudioCtx(context.Background(), chatId, audio, options)
+}
+
+// SendAudio with a context controlling the call.
+func (b *Bot) SendAudioCtx(ctx context.Context, chatId int64, audio InputFile, options SendMediaOptions) (*Message, error) {
+
+	val := url.Values{}
+	setDuration(val, options)
+
+	if options.Performer != "" {
+		val["performer"] = []string{options.Performer}
+	}
+
+	if options.Title != "" {
+		val["title"] = []string{options.Title}
+	}
+
+	return b.sendMedia(ctx, sendAudioEndpoint, chatId, "audio", &audio, options, val)
+}
+
+// Send the video in the chat chatId.
+func (b *Bot) SendVideo(chatId int64, video InputFile, options SendMediaOptions) (*Message, error) {
+	return b.SendVideoCtx(context.Background(), chatId, video, options)
+}
+
+// SendVideo with a context controlling the call.
+func (b *Bot) SendVideoCtx(ctx context.Context, chatId int64, video InputFile, options SendMediaOptions) (*Message, error) {
+
+	val := url.Values{}
+	setDuration(val, options)
+	setSize(val, options)
+
+	if options.SupportsStreaming {
+		val["supports_streaming"] = []string{"true"}
+	}
+
+	return b.sendMedia(ctx, sendVideoEndpoint, chatId, "video", &video, options, val)
+}
+
+// Send the voice message in the chat chatId. The audio must be encoded in OGG with OPUS.
+func (b *Bot) SendVoice(chatId int64, voice InputFile, options SendMediaOptions) (*Message, error) {
+	return b.SendVoiceCtx(context.Background(), chatId, voice, options)
+}
+
+// SendVoice with a context controlling the call.
+func (b *Bot) SendVoiceCtx(ctx context.Context, chatId int64, voice InputFile, options SendMediaOptions) (*Message, error) {
+
+	val := url.Values{}
+	setDuration(val, options)
+
+	return b.sendMedia(ctx, sendVoiceEndpoint, chatId, "voice", &voice, options, val)
+}
+
+// Send the rounded square video message in the chat chatId. Video notes have no caption.
+func (b *Bot) SendVideoNote(chatId int64, videoNote InputFile, options SendMediaOptions) (*Message, error) {
+	return b.SendVideoNoteCtx(context.Background(), chatId, videoNote, options)
+}
+
+// SendVideoNote with a context controlling the call.
+func (b *Bot) SendVideoNoteCtx(ctx context.Context, chatId int64, videoNote InputFile, options SendMediaOptions) (*Message, error) {
+
+	val := url.Values{}
+	setDuration(val, options)
+
+	if options.Length != 0 {
+		val["length"] = []string{strconv.Itoa(options.Length)}
+	}
+
+	options.Caption = ""
+
+	return b.sendMedia(ctx, sendVideoNoteEndpoint, chatId, "video_note", &videoNote, options, val)
+}
+
+// Send the animation (GIF or H.264/MPEG-4 AVC video without sound) in the chat chatId.
+func (b *Bot) SendAnimation(chatId int64, animation InputFile, options SendMediaOptions) (*Message, error) {
+	return b.SendAnimationCtx(context.Background(), chatId, animation, options)
+}
+
+// SendAnimation with a context controlling the call.
+func (b *Bot) SendAnimationCtx(ctx context.Context, chatId int64, animation InputFile, options SendMediaOptions) (*Message, error) {
+
+	val := url.Values{}
+	setDuration(val, options)
+	setSize(val, options)
+
+	return b.sendMedia(ctx, sendAnimationEndpoint, chatId, "animation", &animation, options, val)
+}
+
+// Send the items as an album in the chat chatId. Photos and videos can be mixed, while documents and audios
+// can only be grouped with items of the same type. Return the messages sent.
+func (b *Bot) SendMediaGroup(chatId int64, media []InputMedia, options SendMessageOptions) ([]Message, error) {
+	return b.SendMediaGroupCtx(context.Background(), chatId, media, options)
+}
+
+// SendMediaGroup with a context controlling the call.
+func (b *Bot) SendMediaGroupCtx(ctx context.Context, chatId int64, media []InputMedia, options SendMessageOptions) ([]Message, error) {
+
+	if !isValidMediaGroup(media) {
+		return nil, ErrInvalidMediaGroup
+	}
+
+	// Mandatory arguments.
+	val := url.Values{
+		"chat_id":                     {strconv.FormatInt(chatId, 10)},
+		"disable_notification":        {strconv.FormatBool(options.DisableNotification)},
+		"allow_sending_without_reply": {strconv.FormatBool(options.AllowSendingWithoutReply)},
+	}
+
+	// Reply to message
+	if options.ReplyToMessageId != 0 {
+		val["reply_to_message_id"] = []string{strconv.Itoa(options.ReplyToMessageId)}
+	}
+
+	// The files and thumbnails to upload are copied once, so that readers consumed by a first attempt
+	// are read again from their start when the files are uploaded again.
+	uploads := make([]InputFile, len(media))
+	thumbs := make([]*InputFile, len(media))
+
+	for i, m := range media {
+		uploads[i] = m.Media
+
+		if m.Thumb != nil {
+			thumb := *m.Thumb
+			thumbs[i] = &thumb
+		}
+	}
+
+	// Files already uploaded are sent again with their file_id.
+	keys := make([]string, len(media))
+	files := make([]*InputFile, len(media))
+	fromCache := false
+
+	for i := range media {
+		keys[i] = b.fileCacheKey(media[i].Type, &uploads[i])
+		files[i] = &uploads[i]
+
+		if fileId, ok := b.cachedFileId(keys[i]); ok {
+			cached := FileFromId(fileId)
+			files[i] = &cached
+			fromCache = true
+		}
+	}
+
+	messages, err := b.postMediaGroup(ctx, media, files, thumbs, val)
+
+	// A file_id is no longer valid: upload the files again.
+	if fromCache && isInvalidFileId(err) {
+		for i := range media {
+			if !files[i].isUpload() && keys[i] != "" {
+				b.forgetFileId(keys[i])
+			}
+			files[i] = &uploads[i]
+		}
+
+		messages, err = b.postMediaGroup(ctx, media, files, thumbs, val)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages are returned in the order of the items.
+	if len(messages) == len(media) {
+		for i := range media {
+			if files[i].isUpload() {
+				b.storeFileId(keys[i], messageFileId(&messages[i], media[i].Type))
+			}
+		}
+	}
+
+	return messages, nil
+}
+
+// Post the items of a media group, whose files are files and thumbnails thumbs, along with the parameters val.
+func (b *Bot) postMediaGroup(ctx context.Context, media []InputMedia, files []*InputFile, thumbs []*InputFile, val url.Values) ([]Message, error) {
+
+	uploads := make(map[string]*InputFile)
+	items := make([]inputMediaJSON, len(media))
+
+	// Uploaded files are attached to the body and referenced by their field with attach://.
+	attach := func(field string, file *InputFile) string {
+
+		if !file.isUpload() {
+			return file.value()
+		}
+
+		uploads[field] = file
+
+		return "attach://" + field
+	}
+
+	for i, m := range media {
+		items[i] = inputMediaJSON{
+			Type:                        m.Type,
+			Media:                       attach("file"+strconv.Itoa(i), files[i]),
+			Caption:                     m.Caption,
+			ParseMode:                   m.ParseMode,
+			Width:                       m.Width,
+			Height:                      m.Height,
+			Duration:                    m.Duration,
+			SupportsStreaming:           m.SupportsStreaming,
+			Performer:                   m.Performer,
+			Title:                       m.Title,
+			DisableContentTypeDetection: m.DisableContentTypeDetection,
+		}
+
+		// Thumbnails are only sent along with new uploads.
+		if thumbs[i] != nil && m.Type != "photo" && files[i].isUpload() {
+			items[i].Thumb = attach("thumb"+strconv.Itoa(i), thumbs[i])
+		}
+	}
+
+	jsonMedia, err := json.Marshal(items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	val["media"] = []string{string(jsonMedia)}
+
+	var messages []Message
+
+	if len(uploads) == 0 {
+		err = b.makeAPICall(ctx, sendMediaGroupEndpoint, val, &messages)
+	} else {
+		err = b.makeMultipartAPICall(ctx, sendMediaGroupEndpoint, val, uploads, nil, &messages)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// Check the number of items of a media group and the types which can be grouped.
+func isValidMediaGroup(media []InputMedia) bool {
+
+	if len(media) < 2 || len(media) > 10 {
+		return false
+	}
+
+	for _, m := range media {
+		switch m.Type {
+		case "photo", "video":
+			if media[0].Type != "photo" && media[0].Type != "video" {
+				return false
+			}
+		case "document", "audio":
+			if m.Type != media[0].Type {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Helper to send the file in the parameter field of the endpoint, along with the common options and the parameters val
+// specific to the endpoint. Uploaded files are sent in a multipart/form-data body.
+func (b *Bot) sendMedia(ctx context.Context, endpoint string, chatId int64, field string, file *InputFile, options SendMediaOptions, val url.Values) (*Message, error) {
+
+	// Mandatory arguments.
+	val["chat_id"] = []string{strconv.FormatInt(chatId, 10)}
+	val["disable_notification"] = []string{strconv.FormatBool(options.DisableNotification)}
+	val["allow_sending_without_reply"] = []string{strconv.FormatBool(options.AllowSendingWithoutReply)}
+
+	// Caption and its parse mode
+	if options.Caption != "" {
+		val["caption"] = []string{options.Caption}
+
+		if options.ParseMode != "" {
+			val["parse_mode"] = []string{options.ParseMode}
+		}
+	}
+
+	// Reply to message
+	if options.ReplyToMessageId != 0 {
+		val["reply_to_message_id"] = []string{strconv.Itoa(options.ReplyToMessageId)}
+	}
+
+	// Files already uploaded are sent again with their file_id.
+	key := b.fileCacheKey(field, file)
+
+	if fileId, ok := b.cachedFileId(key); ok {
+		cached := FileFromId(fileId)
+		message, err := b.postMedia(ctx, endpoint, field, &cached, options, val)
+
+		if !isInvalidFileId(err) {
+			return message, err
+		}
+
+		// The file_id is no longer valid: upload the file again.
+		b.forgetFileId(key)
+	}
+
+	message, err := b.postMedia(ctx, endpoint, field, file, options, val)
+
+	if err == nil {
+		b.storeFileId(key, messageFileId(message, field))
+	}
+
+	return message, err
+}
+
+// Post the file in the parameter field of the endpoint, along with the parameters val.
+func (b *Bot) postMedia(ctx context.Context, endpoint string, field string, file *InputFile, options SendMediaOptions, val url.Values) (*Message, error) {
+
+	// Files.
+	files := make(map[string]*InputFile)
+	addInputFile(val, files, field, file)
+
+	// Thumbnails are only sent along with new uploads.
+	if options.Thumb != nil && endpoint != sendPhotoEndpoint && file.isUpload() {
+		thumb := *options.Thumb
+		addInputFile(val, files, "thumb", &thumb)
+	}
+
+	// Files sent by URL or file_id fit in a url encoded body.
+	if len(files) == 0 {
+		return b.makeMessageAPICall(ctx, endpoint, val)
+	}
+
+	var message Message
+
+	if err := b.makeMultipartAPICall(ctx, endpoint, val, files, options.Progress, &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// Set the duration parameter of a file.
+func setDuration(val url.Values, options SendMediaOptions) {
+
+	if options.Duration != 0 {
+		val["duration"] = []string{strconv.Itoa(options.Duration)}
+	}
+}
+
+// Set the size parameters of a video or an animation.
+func setSize(val url.Values, options SendMediaOptions) {
+
+	if options.Width != 0 {
+		val["width"] = []string{strconv.Itoa(options.Width)}
+	}
+
+	if options.Height != 0 {
+		val["height"] = []string{strconv.Itoa(options.Height)}
+	}
+}
+
+// InputMedia Object of the Telegram API, sent in the media parameter of sendMediaGroup.
+type inputMediaJSON struct {
+	Type                        string `json:"type"`
+	Media                       string `json:"media"`
+	Thumb                       string `json:"thumb,omitempty"`
+	Caption                     string `json:"caption,omitempty"`
+	ParseMode                   string `json:"parse_mode,omitempty"`
+	Width                       int    `json:"width,omitempty"`
+	Height                      int    `json:"height,omitempty"`
+	Duration                    int    `json:"duration,omitempty"`
+	SupportsStreaming           bool   `json:"supports_streaming,omitempty"`
+	Performer                   string `json:"performer,omitempty"`
+	Title                       string `json:"title,omitempty"`
+	DisableContentTypeDetection bool   `json:"disable_content_type_detection,omitempty"`
+}
@@ -0,0 +1,52 @@
+package telebot
+
+import (
+	"testing"
+)
+
+func TestIsValidMediaGroup(t *testing.T) {
+
+	// Build a media group with items of the given types.
+	group := func(types ...string) []InputMedia {
+		media := make([]InputMedia, len(types))
+		for i, mediaType := range types {
+			media[i] = InputMedia{Type: mediaType, Media: FileFromId("file")}
+		}
+		return media
+	}
+
+	ten := make([]string, 10)
+	for i := range ten {
+		ten[i] = "photo"
+	}
+
+	tests := []struct {
+		name  string
+		media []InputMedia
+		want  bool
+	}{
+		{"photos", group("photo", "photo"), true},
+		{"photos and videos", group("photo", "video", "photo"), true},
+		{"videos then photos", group("video", "photo"), true},
+		{"documents", group("document", "document"), true},
+		{"audios", group("audio", "audio", "audio"), true},
+		{"ten items", group(ten...), true},
+		{"empty", group(), false},
+		{"single item", group("photo"), false},
+		{"eleven items", group(append(ten, "photo")...), false},
+		{"documents and photos", group("document", "photo"), false},
+		{"photos and documents", group("photo", "document"), false},
+		{"documents and audios", group("document", "audio"), false},
+		{"audios and videos", group("audio", "video"), false},
+		{"animations", group("animation", "animation"), false},
+		{"unknown type", group("photo", ""), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isValidMediaGroup(test.media); got != test.want {
+				t.Errorf("isValidMediaGroup(%s) = %v, want %v", test.name, got, test.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package telebot
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/url"
@@ -8,11 +9,16 @@ import (
 )
 
 // Send the message text in the chat chatId.
-func (b *Bot) SendTextMessage(chatId int, text string, options SendMessageOptions) (string, error) {
+func (b *Bot) SendTextMessage(chatId int64, text string, options SendMessageOptions) (*Message, error) {
+	return b.SendTextMessageCtx(context.Background(), chatId, text, options)
+}
+
+// SendTextMessage with a context controlling the call.
+func (b *Bot) SendTextMessageCtx(ctx context.Context, chatId int64, text string, options SendMessageOptions) (*Message, error) {
 
 	// Mandatory arguments.
 	val := url.Values{
-		"chat_id":                     {strconv.Itoa(chatId)},
+		"chat_id":                     {strconv.FormatInt(chatId, 10)},
 		"text":                        {text},
 		"disable_web_page_preview":    {strconv.FormatBool(options.DisableWebPagePreview)},
 		"disable_notification":        {strconv.FormatBool(options.DisableNotification)},
@@ -29,12 +35,17 @@ func (b *Bot) SendTextMessage(chatId int, text string, options SendMessageOption
 		val["reply_to_message_id"] = []string{strconv.Itoa(options.ReplyToMessageId)}
 	}
 
-	return b.makeAPICall(sendMessageEndpoint, val)
+	return b.makeMessageAPICall(ctx, sendMessageEndpoint, val)
 
 }
 
 // Send a text message with a ReplyKeyboardMarkup keyboard
-func (b *Bot) SendReplyKeyboardMarkupTextMessage(chatId int, text string, keyboard ReplyKeyboardMarkup, options SendMessageOptions) (string, error) {
+func (b *Bot) SendReplyKeyboardMarkupTextMessage(chatId int64, text string, keyboard ReplyKeyboardMarkup, options SendMessageOptions) (*Message, error) {
+	return b.SendReplyKeyboardMarkupTextMessageCtx(context.Background(), chatId, text, keyboard, options)
+}
+
+// SendReplyKeyboardMarkupTextMessage with a context controlling the call.
+func (b *Bot) SendReplyKeyboardMarkupTextMessageCtx(ctx context.Context, chatId int64, text string, keyboard ReplyKeyboardMarkup, options SendMessageOptions) (*Message, error) {
 
 	jsonStr, err := json.Marshal(keyboard)
 
@@ -46,7 +57,7 @@ func (b *Bot) SendReplyKeyboardMarkupTextMessage(chatId int, text string, keyboa
 
 	// Mandatory arguments.
 	val := url.Values{
-		"chat_id":                     {strconv.Itoa(chatId)},
+		"chat_id":                     {strconv.FormatInt(chatId, 10)},
 		"text":                        {text},
 		"disable_web_page_preview":    {strconv.FormatBool(options.DisableWebPagePreview)},
 		"disable_notification":        {strconv.FormatBool(options.DisableNotification)},
@@ -64,12 +75,17 @@ func (b *Bot) SendReplyKeyboardMarkupTextMessage(chatId int, text string, keyboa
 		val["reply_to_message_id"] = []string{strconv.Itoa(options.ReplyToMessageId)}
 	}
 
-	return b.makeAPICall(sendMessageEndpoint, val)
+	return b.makeMessageAPICall(ctx, sendMessageEndpoint, val)
 
 }
 
 // Send a text message with a ReplyKeyboardRemove keyboard
-func (b *Bot) SendReplyKeyboardRemoveTextMessage(chatId int, text string, selective bool, options SendMessageOptions) (string, error) {
+func (b *Bot) SendReplyKeyboardRemoveTextMessage(chatId int64, text string, selective bool, options SendMessageOptions) (*Message, error) {
+	return b.SendReplyKeyboardRemoveTextMessageCtx(context.Background(), chatId, text, selective, options)
+}
+
+// SendReplyKeyboardRemoveTextMessage with a context controlling the call.
+func (b *Bot) SendReplyKeyboardRemoveTextMessageCtx(ctx context.Context, chatId int64, text string, selective bool, options SendMessageOptions) (*Message, error) {
 
 	keyboard := ReplyKeyboardRemove{RemoveKeyboard: true, Selective: selective}
 
@@ -83,7 +99,7 @@ func (b *Bot) SendReplyKeyboardRemoveTextMessage(chatId int, text string, select
 
 	// Mandatory arguments.
 	val := url.Values{
-		"chat_id":                     {strconv.Itoa(chatId)},
+		"chat_id":                     {strconv.FormatInt(chatId, 10)},
 		"text":                        {text},
 		"disable_web_page_preview":    {strconv.FormatBool(options.DisableWebPagePreview)},
 		"disable_notification":        {strconv.FormatBool(options.DisableNotification)},
@@ -101,12 +117,17 @@ func (b *Bot) SendReplyKeyboardRemoveTextMessage(chatId int, text string, select
 		val["reply_to_message_id"] = []string{strconv.Itoa(options.ReplyToMessageId)}
 	}
 
-	return b.makeAPICall(sendMessageEndpoint, val)
+	return b.makeMessageAPICall(ctx, sendMessageEndpoint, val)
 
 }
 
 // Send a text message with an inline keyboard
-func (b *Bot) SendInlineKeyboardMarkupTextMessage(chatId int, text string, keyboard InlineKeyboardMarkup, options SendMessageOptions) (string, error) {
+func (b *Bot) SendInlineKeyboardMarkupTextMessage(chatId int64, text string, keyboard InlineKeyboardMarkup, options SendMessageOptions) (*Message, error) {
+	return b.SendInlineKeyboardMarkupTextMessageCtx(context.Background(), chatId, text, keyboard, options)
+}
+
+// SendInlineKeyboardMarkupTextMessage with a context controlling the call.
+func (b *Bot) SendInlineKeyboardMarkupTextMessageCtx(ctx context.Context, chatId int64, text string, keyboard InlineKeyboardMarkup, options SendMessageOptions) (*Message, error) {
 
 	jsonKeyboard, err := json.Marshal(keyboard)
 
@@ -116,7 +137,7 @@ func (b *Bot) SendInlineKeyboardMarkupTextMessage(chatId int, text string, keybo
 
 	// Mandatory arguments.
 	val := url.Values{
-		"chat_id":                     {strconv.Itoa(chatId)},
+		"chat_id":                     {strconv.FormatInt(chatId, 10)},
 		"text":                        {text},
 		"disable_web_page_preview":    {strconv.FormatBool(options.DisableWebPagePreview)},
 		"disable_notification":        {strconv.FormatBool(options.DisableNotification)},
@@ -134,16 +155,21 @@ func (b *Bot) SendInlineKeyboardMarkupTextMessage(chatId int, text string, keybo
 		val["reply_to_message_id"] = []string{strconv.Itoa(options.ReplyToMessageId)}
 	}
 
-	return b.makeAPICall(sendMessageEndpoint, val)
+	return b.makeMessageAPICall(ctx, sendMessageEndpoint, val)
 
 }
 
 // Edit a text message.
-func (b *Bot) EditTextMessage(chatId int, newText string, messageId int, options SendMessageOptions) (string, error) {
+func (b *Bot) EditTextMessage(chatId int64, newText string, messageId int, options SendMessageOptions) (*Message, error) {
+	return b.EditTextMessageCtx(context.Background(), chatId, newText, messageId, options)
+}
+
+// EditTextMessage with a context controlling the call.
+func (b *Bot) EditTextMessageCtx(ctx context.Context, chatId int64, newText string, messageId int, options SendMessageOptions) (*Message, error) {
 
 	// Mandatory arguments.
 	val := url.Values{
-		"chat_id":                  {strconv.Itoa(chatId)},
+		"chat_id":                  {strconv.FormatInt(chatId, 10)},
 		"message_id":               {strconv.Itoa(messageId)},
 		"text":                     {newText},
 		"disable_web_page_preview": {strconv.FormatBool(options.DisableWebPagePreview)},
@@ -154,11 +180,16 @@ func (b *Bot) EditTextMessage(chatId int, newText string, messageId int, options
 		val["parse_mode"] = []string{options.ParseMode}
 	}
 
-	return b.makeAPICall(editMessageTextEndpoint, val)
+	return b.makeMessageAPICall(ctx, editMessageTextEndpoint, val)
 }
 
 // Edit a text message with InlineKeyboardMarkup
-func (b *Bot) EditInlineKeyboardTextMessage(chatId int, newText string, messageId int, newKeyboard InlineKeyboardMarkup, options SendMessageOptions) (string, error) {
+func (b *Bot) EditInlineKeyboardTextMessage(chatId int64, newText string, messageId int, newKeyboard InlineKeyboardMarkup, options SendMessageOptions) (*Message, error) {
+	return b.EditInlineKeyboardTextMessageCtx(context.Background(), chatId, newText, messageId, newKeyboard, options)
+}
+
+// EditInlineKeyboardTextMessage with a context controlling the call.
+func (b *Bot) EditInlineKeyboardTextMessageCtx(ctx context.Context, chatId int64, newText string, messageId int, newKeyboard InlineKeyboardMarkup, options SendMessageOptions) (*Message, error) {
 
 	jsonKeyboard, err := json.Marshal(newKeyboard)
 
@@ -168,7 +199,7 @@ func (b *Bot) EditInlineKeyboardTextMessage(chatId int, newText string, messageI
 
 	// Mandatory arguments.
 	val := url.Values{
-		"chat_id":                  {strconv.Itoa(chatId)},
+		"chat_id":                  {strconv.FormatInt(chatId, 10)},
 		"message_id":               {strconv.Itoa(messageId)},
 		"text":                     {newText},
 		"disable_web_page_preview": {strconv.FormatBool(options.DisableWebPagePreview)},
@@ -180,11 +211,16 @@ func (b *Bot) EditInlineKeyboardTextMessage(chatId int, newText string, messageI
 		val["parse_mode"] = []string{options.ParseMode}
 	}
 
-	return b.makeAPICall(editMessageTextEndpoint, val)
+	return b.makeMessageAPICall(ctx, editMessageTextEndpoint, val)
 }
 
 // Edit the inline keyboard of a message
-func (b *Bot) EditMessageInlineKeyboardMarkup(chatId int, messageId int, newKeyboard InlineKeyboardMarkup) (string, error) {
+func (b *Bot) EditMessageInlineKeyboardMarkup(chatId int64, messageId int, newKeyboard InlineKeyboardMarkup) (*Message, error) {
+	return b.EditMessageInlineKeyboardMarkupCtx(context.Background(), chatId, messageId, newKeyboard)
+}
+
+// EditMessageInlineKeyboardMarkup with a context controlling the call.
+func (b *Bot) EditMessageInlineKeyboardMarkupCtx(ctx context.Context, chatId int64, messageId int, newKeyboard InlineKeyboardMarkup) (*Message, error) {
 
 	jsonKeyboard, err := json.Marshal(newKeyboard)
 
@@ -194,24 +230,29 @@ func (b *Bot) EditMessageInlineKeyboardMarkup(chatId int, messageId int, newKeyb
 
 	// Mandatory arguments.
 	val := url.Values{
-		"chat_id":      {strconv.Itoa(chatId)},
+		"chat_id":      {strconv.FormatInt(chatId, 10)},
 		"message_id":   {strconv.Itoa(messageId)},
 		"reply_markup": {string(jsonKeyboard)},
 	}
 
-	return b.makeAPICall(editMessageReplyMarkupEndpoint, val)
+	return b.makeMessageAPICall(ctx, editMessageReplyMarkupEndpoint, val)
 
 }
 
 // Delete a message
-func (b *Bot) DeleteMessage(chatId int, messageId int) (string, error) {
+func (b *Bot) DeleteMessage(chatId int64, messageId int) (bool, error) {
+	return b.DeleteMessageCtx(context.Background(), chatId, messageId)
+}
+
+// DeleteMessage with a context controlling the call.
+func (b *Bot) DeleteMessageCtx(ctx context.Context, chatId int64, messageId int) (bool, error) {
 
 	// Mandatory arguments.
 	val := url.Values{
-		"chat_id":    {strconv.Itoa(chatId)},
+		"chat_id":    {strconv.FormatInt(chatId, 10)},
 		"message_id": {strconv.Itoa(messageId)},
 	}
 
-	return b.makeAPICall(deleteMessageEndpoint, val)
+	return b.makeBoolAPICall(ctx, deleteMessageEndpoint, val)
 
 }
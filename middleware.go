@@ -0,0 +1,130 @@
+package telebot
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a handler to run code before and after it, or to decide whether it runs.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Add global middlewares, running around every handler of the bot.
+// Middlewares run in the order they were added: the first one is the outermost.
+func (b *Bot) Use(middlewares ...Middleware) {
+
+	b.registryMu.Lock()
+	defer b.registryMu.Unlock()
+
+	b.globalMiddlewares = appendMiddlewares(b.globalMiddlewares, middlewares)
+}
+
+// Append middlewares to a copy of list, so that the slices read by dispatched updates are never modified.
+func appendMiddlewares(list []Middleware, middlewares []Middleware) []Middleware {
+
+	result := make([]Middleware, 0, len(list)+len(middlewares))
+	result = append(result, list...)
+
+	return append(result, middlewares...)
+}
+
+// Wrap handler with middlewares, the first middleware being the outermost.
+func chainMiddlewares(middlewares []Middleware, handler HandlerFunc) HandlerFunc {
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+//
+// Below are defined the middlewares shipped with telebot.
+//
+
+// Log every update handled with its chat, the time spent in the handler and its error.
+func Logger() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			start := time.Now()
+			err := next(c)
+
+			if err != nil {
+				log.Printf("Update %d from chat %d handled in %s with error: %s", c.Update.UpdateId, updateChatId(c.Update), time.Since(start), err.Error())
+			} else {
+				log.Printf("Update %d from chat %d handled in %s", c.Update.UpdateId, updateChatId(c.Update), time.Since(start))
+			}
+
+			return err
+		}
+	}
+}
+
+// Recover from panics in handlers and keep the bot running.
+// The panic is logged with its stack trace and returned as an error to the error handler.
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Panic while handling update %d: %v\n%s", c.Update.UpdateId, r, debug.Stack())
+					err = fmt.Errorf("telebot: panic while handling update %d: %v", c.Update.UpdateId, r)
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// Report the time spent in handlers to the function report, for instance to feed a metric.
+func Timing(report func(c *Context, duration time.Duration)) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			start := time.Now()
+			err := next(c)
+			report(c, time.Since(start))
+
+			return err
+		}
+	}
+}
+
+// Only run handlers for updates sent by the users userIds. Other updates are ignored.
+func AllowUsers(userIds ...int64) Middleware {
+
+	allowed := make(map[int64]bool, len(userIds))
+	for _, id := range userIds {
+		allowed[id] = true
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			if !allowed[updateSenderId(c.Update)] {
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Only run handlers for updates from the chats chatIds. Other updates are ignored.
+func AllowChats(chatIds ...int64) Middleware {
+
+	allowed := make(map[int64]bool, len(chatIds))
+	for _, id := range chatIds {
+		allowed[id] = true
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			if !allowed[updateChatId(c.Update)] {
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package telebot
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures a Bot at creation.
+type Option func(b *Bot)
+
+// Fetch updates with long polling on the /getUpdates endpoint. This is the default mode.
+func WithPolling() Option {
+	return func(b *Bot) {
+		b.mode = PollingMode
+		b.webhook = WebhookConfig{}
+	}
+}
+
+// Receive updates on a webhook configured with config.
+func WithWebhook(config WebhookConfig) Option {
+	return func(b *Bot) {
+		b.mode = WebhookMode
+		b.webhook = config
+	}
+}
+
+// Set the policy used to retry failed Telegram API calls.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(b *Bot) {
+		b.retryPolicy = policy
+	}
+}
+
+// Set the limits of the outgoing rate limiter.
+func WithRateLimits(limits RateLimits) Option {
+	return func(b *Bot) {
+		b.limiter = newRateLimiter(limits)
+	}
+}
+
+// Set the HTTP client used to call the Telegram API (timeouts, proxies, custom transport...).
+func WithHTTPClient(client *http.Client) Option {
+	return func(b *Bot) {
+		b.httpClient = client
+	}
+}
+
+// Set the base URL of the Telegram API, for instance to use a self-hosted Bot API server.
+// The default is https://api.telegram.org.
+func WithBaseURL(baseUrl string) Option {
+	return func(b *Bot) {
+		b.baseUrl = strings.TrimSuffix(baseUrl, "/")
+	}
+}
+
+// Use a Bot API server running with --local, along with WithBaseURL: files larger than 20 MB can be downloaded,
+// and the absolute file paths returned by getFile are read from the local filesystem.
+func WithLocalAPIServer() Option {
+	return func(b *Bot) {
+		b.localAPIServer = true
+	}
+}
+
+// Cache the file_id of the uploaded files in cache, and send identical files again with their file_id
+// instead of uploading them. A nil cache keeps the file_ids in memory.
+func WithFileCache(cache FileCache) Option {
+	return func(b *Bot) {
+		if cache == nil {
+			cache = NewMemoryFileCache()
+		}
+
+		b.fileCache = cache
+	}
+}
+
+// Set how long a getUpdates long polling request waits for updates. The default is 30 seconds.
+// The timeout of the HTTP client must be longer than the poll timeout.
+func WithPollTimeout(timeout time.Duration) Option {
+	return func(b *Bot) {
+		b.pollTimeout = timeout
+	}
+}
+
+// Set the maximum number of updates fetched by a getUpdates request (1-100). The default is 100.
+func WithPollLimit(limit int) Option {
+	return func(b *Bot) {
+		b.pollLimit = limit
+	}
+}
+
+// Set the types of updates the bot receives (for instance "message" or "callback_query").
+// Without this option, Telegram sends all update types except chat_member.
+func WithAllowedUpdates(updateTypes ...string) Option {
+	return func(b *Bot) {
+		b.allowedUpdates = updateTypes
+	}
+}
+
+// Set how long Stop waits for in-flight handlers and webhook requests. The default is 10 seconds.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(b *Bot) {
+		b.shutdownTimeout = timeout
+	}
+}
+
+// Set the number of workers handling updates concurrently. The default is 64.
+// Updates from the same chat are always handled one at a time, in order.
+func WithWorkers(workers int) Option {
+	return func(b *Bot) {
+		b.workers = workers
+	}
+}
+
+// Set the maximum number of updates waiting to be handled, all chats included. The default is 1024.
+// When it is reached, the reception of updates blocks until an update is taken by a worker.
+func WithQueueSize(size int) Option {
+	return func(b *Bot) {
+		b.queueSize = size
+	}
+}
+
+// Set the address on which the webhook server listens. The default is ":8443".
+func WithListenAddr(addr string) Option {
+	return func(b *Bot) {
+		b.listenAddr = addr
+	}
+}
+
+// Serve the webhook over plain HTTP, for bots running behind a reverse proxy terminating TLS.
+func WithPlainHTTP() Option {
+	return func(b *Bot) {
+		b.plainHTTP = true
+	}
+}
+
+// Serve the webhook with a custom server (timeouts, TLS config...).
+// If the server has no handler, the webhook handler is mounted on the webhook path.
+// A server cannot be reused once the bot is stopped.
+func WithServer(server *http.Server) Option {
+	return func(b *Bot) {
+		b.webhookServer = server
+	}
+}
+
+// Do not start a webhook server: Start only registers the webhook with Telegram
+// and the caller routes the requests to the handler returned by WebhookHandler.
+func WithoutWebhookServer() Option {
+	return func(b *Bot) {
+		b.noWebhookServer = true
+	}
+}
+
+// Set the secret token sent by Telegram in the X-Telegram-Bot-Api-Secret-Token header of webhook requests.
+// Requests without the right token are rejected.
+func WithSecretToken(token string) Option {
+	return func(b *Bot) {
+		b.secretToken = token
+	}
+}
+
+// Set the maximum size in bytes of the body of a webhook request. The default is 1 MiB.
+func WithMaxBodySize(size int64) Option {
+	return func(b *Bot) {
+		b.maxBodySize = size
+	}
+}
+
+// Only accept webhook requests from the given networks, for instance TelegramSubnets.
+// The remote address of the request is checked: behind a reverse proxy, filter the requests in the proxy instead.
+func WithSourceAllowlist(subnets ...*net.IPNet) Option {
+	return func(b *Bot) {
+		b.sourceAllowlist = subnets
+	}
+}
+
+// Upload the public key certificate at path when setting the webhook, for webhooks using a self-signed certificate.
+func WithWebhookCertificate(path string) Option {
+	return func(b *Bot) {
+		b.webhookCertificate = path
+	}
+}
+
+// Set the maximum number of simultaneous connections Telegram opens to the webhook (1-100).
+func WithMaxConnections(maxConnections int) Option {
+	return func(b *Bot) {
+		b.maxConnections = maxConnections
+	}
+}
+
+// Drop the updates pending on Telegram side when the webhook is set or deleted on start.
+func WithDropPendingUpdates() Option {
+	return func(b *Bot) {
+		b.dropPendingUpdates = true
+	}
+}
@@ -0,0 +1,134 @@
+package telebot
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimits configures the limiter throttling the messages sent to chats.
+// A zero value disables the corresponding limit.
+type RateLimits struct {
+	// Maximum number of messages per second, all chats included.
+	Global int
+	// Maximum number of messages per second to a single private chat.
+	PerChat int
+	// Maximum number of messages per minute to a single group or channel.
+	PerGroup int
+}
+
+// Limits used by bots created without WithRateLimits. They match the limits documented by Telegram.
+var DefaultRateLimits = RateLimits{
+	Global:   30,
+	PerChat:  1,
+	PerGroup: 20,
+}
+
+// Number of reservations between two cleanups of the per chat schedule.
+const rateLimiterSweepInterval = 1024
+
+// rateLimiter queues messages until they can be sent without exceeding the rate limits.
+// Slots are only reserved once they are reached, so that calls cancelled while waiting do not delay the next ones.
+type rateLimiter struct {
+	limits RateLimits
+
+	mu         sync.Mutex
+	nextGlobal time.Time
+	nextChat   map[string]time.Time
+	sweep      int
+
+	// Number of calls currently waiting for a slot.
+	queued int64
+}
+
+// Create a rate limiter enforcing limits.
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{limits: limits, nextChat: make(map[string]time.Time)}
+}
+
+// Block until count messages can be sent to the chat chatId or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, chatId string, count int) error {
+
+	atomic.AddInt64(&l.queued, 1)
+	defer atomic.AddInt64(&l.queued, -1)
+
+	for {
+		delay := l.reserve(chatId, count, time.Now())
+
+		if delay <= 0 {
+			return nil
+		}
+
+		if err := sleepCtx(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Reserve the slots of count messages to the chat chatId if the chat and global limits allow sending them at now.
+// Otherwise, nothing is reserved and the delay before the next attempt is returned.
+func (l *rateLimiter) reserve(chatId string, count int, now time.Time) time.Duration {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Forget the chats whose slot is in the past from time to time to bound memory usage.
+	l.sweep++
+	if l.sweep >= rateLimiterSweepInterval {
+		l.sweep = 0
+		for id, next := range l.nextChat {
+			if next.Before(now) {
+				delete(l.nextChat, id)
+			}
+		}
+	}
+
+	// Wait for the turn of the chat and for a global slot.
+	delay := l.nextChat[chatId].Sub(now)
+	if globalDelay := l.nextGlobal.Sub(now); globalDelay > delay {
+		delay = globalDelay
+	}
+
+	if delay > 0 {
+		return delay
+	}
+
+	if interval := l.chatInterval(chatId); interval > 0 {
+		l.nextChat[chatId] = now.Add(time.Duration(count) * interval)
+	}
+
+	if l.limits.Global > 0 {
+		l.nextGlobal = now.Add(time.Duration(count) * time.Second / time.Duration(l.limits.Global))
+	}
+
+	return 0
+}
+
+// Minimal interval between two messages to the chat chatId.
+// Group and channel ids are negative, channels can also be targeted by @username.
+func (l *rateLimiter) chatInterval(chatId string) time.Duration {
+
+	if strings.HasPrefix(chatId, "-") || strings.HasPrefix(chatId, "@") {
+		if l.limits.PerGroup <= 0 {
+			return 0
+		}
+		return time.Minute / time.Duration(l.limits.PerGroup)
+	}
+
+	if l.limits.PerChat <= 0 {
+		return 0
+	}
+	return time.Second / time.Duration(l.limits.PerChat)
+}
+
+// Number of calls waiting for the rate limiter.
+func (l *rateLimiter) depth() int {
+	return int(atomic.LoadInt64(&l.queued))
+}
+
+// Return the number of outgoing calls currently queued by the rate limiter.
+func (b *Bot) QueueDepth() int {
+	return b.limiter.depth()
+}
@@ -0,0 +1,132 @@
+package telebot
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+
+	now := time.Now()
+
+	type step struct {
+		chatId string
+		count  int
+		after  time.Duration
+		// Delay returned by reserve, zero if the slots are reserved.
+		want time.Duration
+	}
+
+	tests := []struct {
+		name   string
+		limits RateLimits
+		steps  []step
+	}{
+		{"private chat", RateLimits{PerChat: 1}, []step{
+			{"1", 1, 0, 0},
+			{"1", 1, 0, time.Second},
+			{"1", 1, 400 * time.Millisecond, 600 * time.Millisecond},
+			{"1", 1, time.Second, 0},
+		}},
+		{"group", RateLimits{PerGroup: 20}, []step{
+			{"-1", 1, 0, 0},
+			{"-1", 1, time.Second, 2 * time.Second},
+			{"@channel", 1, time.Second, 0},
+			{"-1", 1, 3 * time.Second, 0},
+		}},
+		{"chats are independent", RateLimits{PerChat: 1, PerGroup: 20}, []step{
+			{"1", 1, 0, 0},
+			{"2", 1, 0, 0},
+			{"-1", 1, 0, 0},
+		}},
+		{"global", RateLimits{Global: 10}, []step{
+			{"1", 1, 0, 0},
+			{"2", 1, 0, 100 * time.Millisecond},
+			{"2", 1, 100 * time.Millisecond, 0},
+		}},
+		{"media group", RateLimits{Global: 10, PerChat: 1}, []step{
+			{"1", 10, 0, 0},
+			{"2", 1, 0, time.Second},
+			{"1", 1, time.Second, 9 * time.Second},
+		}},
+		{"no limits", RateLimits{}, []step{
+			{"1", 1, 0, 0},
+			{"1", 10, 0, 0},
+			{"-1", 1, 0, 0},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newRateLimiter(test.limits)
+
+			for i, step := range test.steps {
+				if got := l.reserve(step.chatId, step.count, now.Add(step.after)); got != step.want {
+					t.Errorf("step %d: reserve(%s, %d) = %s, want %s", i, step.chatId, step.count, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterCancelledWaitKeepsNoSlot(t *testing.T) {
+
+	l := newRateLimiter(RateLimits{PerGroup: 20})
+
+	if err := l.wait(context.Background(), "-1", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Calls cancelled while waiting for their turn.
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		if err := l.wait(ctx, "-1", 1); err == nil {
+			t.Fatal("wait() returned before the turn of the chat")
+		}
+		cancel()
+	}
+
+	// The next call only waits for the slot following the first call.
+	if delay := l.reserve("-1", 1, time.Now()); delay > 3*time.Second {
+		t.Errorf("reserve() after cancelled calls = %s, want at most 3s", delay)
+	}
+
+	if depth := l.depth(); depth != 0 {
+		t.Errorf("depth() = %d, want 0", depth)
+	}
+}
+
+func TestMessageCount(t *testing.T) {
+
+	tests := []struct {
+		endpoint string
+		media    string
+		want     int
+	}{
+		{sendMessageEndpoint, "", 1},
+		{sendMediaGroupEndpoint, `[{"type":"photo"},{"type":"photo"},{"type":"video"}]`, 3},
+		{sendMediaGroupEndpoint, "", 1},
+		{sendPhotoEndpoint, `[{"type":"photo"},{"type":"photo"}]`, 1},
+	}
+
+	for _, test := range tests {
+		if got := messageCount(test.endpoint, url.Values{"media": {test.media}}); got != test.want {
+			t.Errorf("messageCount(%s, %s) = %d, want %d", test.endpoint, test.media, got, test.want)
+		}
+	}
+
+	for endpoint, want := range map[string]bool{
+		sendMessageEndpoint:     true,
+		sendMediaGroupEndpoint:  true,
+		deleteMessageEndpoint:   false,
+		editMessageTextEndpoint: false,
+		kickChatMemberEndpoint:  false,
+		unbanChatMemberEndpoint: false,
+	} {
+		if got := isSendEndpoint(endpoint); got != want {
+			t.Errorf("isSendEndpoint(%s) = %v, want %v", endpoint, got, want)
+		}
+	}
+}
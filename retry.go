@@ -0,0 +1,96 @@
+package telebot
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy describes how failed Telegram API calls are retried.
+type RetryPolicy struct {
+	// Maximum number of attempts of a call, the first one included. Values below 2 disable retries.
+	MaxAttempts int
+	// Backoff before the first retry of a network or server error. It doubles on each attempt.
+	BaseDelay time.Duration
+	// Upper bound of the delay between two attempts. Flood control errors asking to wait longer
+	// are returned to the caller instead of being retried. Zero means no bound.
+	MaxDelay time.Duration
+	// Resend calls to the new supergroup when Telegram reports that a group was migrated.
+	FollowMigrations bool
+}
+
+// Retry policy used by bots created without WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:      5,
+	BaseDelay:        500 * time.Millisecond,
+	MaxDelay:         30 * time.Second,
+	FollowMigrations: true,
+}
+
+// Compute the delay before the next attempt of a call which failed with err.
+// The second value is false when the error must be returned to the caller.
+func (p RetryPolicy) retryDelay(attempt int, err error) (time.Duration, bool) {
+
+	var apiErr *APIError
+	var urlErr *url.Error
+	var rewindErr *rewindError
+
+	switch {
+	// Files consumed by the failed attempt cannot be uploaded again.
+	case errors.As(err, &rewindErr):
+		return 0, false
+
+	// Flood control: wait as long as Telegram asks to, unless it is longer than MaxDelay.
+	case errors.As(err, &apiErr) && apiErr.ErrorCode == http.StatusTooManyRequests:
+		if apiErr.Parameters != nil && apiErr.Parameters.RetryAfter > 0 {
+			delay := time.Duration(apiErr.Parameters.RetryAfter) * time.Second
+			return delay, p.MaxDelay <= 0 || delay <= p.MaxDelay
+		}
+		return p.backoff(attempt), true
+
+	// Telegram server errors.
+	case errors.As(err, &apiErr):
+		return p.backoff(attempt), apiErr.ErrorCode >= http.StatusInternalServerError
+
+	// Network errors.
+	case errors.As(err, &urlErr):
+		return p.backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+// Exponential backoff with jitter: a random delay between half and the whole of BaseDelay * 2^(attempt-1).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+
+	// Without MaxDelay, the delay doubles until it would overflow.
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay > 0 && delay <= math.MaxInt64/2 && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Return the id of the supergroup a group migrated to if err reports a migration, 0 otherwise.
+func migratedChatId(err error) int64 {
+
+	var apiErr *APIError
+
+	if errors.As(err, &apiErr) && apiErr.Parameters != nil {
+		return apiErr.Parameters.MigrateToChatId
+	}
+
+	return 0
+}
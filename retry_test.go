@@ -0,0 +1,85 @@
+package telebot
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}
+	serverErr := &APIError{ErrorCode: 500, Description: "Internal Server Error"}
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		// Bounds of the delay, when the call is retried.
+		min, max time.Duration
+		retry    bool
+	}{
+		{"flood control", 1, &APIError{ErrorCode: 429, Parameters: &ResponseParameters{RetryAfter: 3}}, 3 * time.Second, 3 * time.Second, true},
+		{"flood control longer than MaxDelay", 1, &APIError{ErrorCode: 429, Parameters: &ResponseParameters{RetryAfter: 3600}}, 0, 0, false},
+		{"flood control without retry_after", 2, &APIError{ErrorCode: 429}, 100 * time.Millisecond, 200 * time.Millisecond, true},
+		{"server error", 1, serverErr, 50 * time.Millisecond, 100 * time.Millisecond, true},
+		{"bad gateway", 3, &APIError{ErrorCode: 502}, 200 * time.Millisecond, 400 * time.Millisecond, true},
+		{"wrapped server error", 1, fmt.Errorf("send: %w", serverErr), 50 * time.Millisecond, 100 * time.Millisecond, true},
+		{"network error", 4, &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: errors.New("connection reset")}, 400 * time.Millisecond, 800 * time.Millisecond, true},
+		{"bad request", 1, &APIError{ErrorCode: 400, Description: "Bad Request: chat not found"}, 0, 0, false},
+		{"forbidden", 1, &APIError{ErrorCode: 403, Description: "Forbidden: bot was blocked by the user"}, 0, 0, false},
+		{"upload not repeatable", 1, &rewindError{err: errors.New("already read"), lastErr: serverErr}, 0, 0, false},
+		{"other error", 1, errors.New("invalid parameters"), 0, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delay, retry := policy.retryDelay(test.attempt, test.err)
+
+			if retry != test.retry {
+				t.Fatalf("retryDelay(%d, %v) retry = %v, want %v", test.attempt, test.err, retry, test.retry)
+			}
+
+			if retry && (delay < test.min || delay > test.max) {
+				t.Errorf("retryDelay(%d, %v) = %s, want between %s and %s", test.attempt, test.err, delay, test.min, test.max)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		// Delay before jitter: backoff returns a delay between its half and itself.
+		want time.Duration
+	}{
+		{"first attempt", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 1, 100 * time.Millisecond},
+		{"doubles", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 3, 400 * time.Millisecond},
+		{"capped", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 5, time.Second},
+		{"capped after many attempts", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 100, time.Second},
+		{"base above max", RetryPolicy{BaseDelay: 2 * time.Second, MaxDelay: time.Second}, 1, time.Second},
+		{"no max", RetryPolicy{BaseDelay: 100 * time.Millisecond}, 4, 800 * time.Millisecond},
+		{"no base", RetryPolicy{MaxDelay: time.Second}, 3, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				if got := test.policy.backoff(test.attempt); got < test.want/2 || got > test.want {
+					t.Fatalf("backoff(%d) = %s, want between %s and %s", test.attempt, got, test.want/2, test.want)
+				}
+			}
+		})
+	}
+
+	// The delay does not overflow without an upper bound.
+	policy := RetryPolicy{BaseDelay: time.Second}
+	if got := policy.backoff(100); got <= 0 {
+		t.Errorf("backoff(100) without MaxDelay = %s, want a positive delay", got)
+	}
+}
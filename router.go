@@ -0,0 +1,259 @@
+package telebot
+
+import "regexp"
+
+// HandlerFunc handles an update. A non nil error is passed to the error handler of the bot.
+type HandlerFunc func(c *Context) error
+
+// router registers handlers on a bot. Handlers registered through a Group are wrapped with the middlewares of the group.
+type router struct {
+	bot         *Bot
+	parent      *router
+	middlewares []Middleware
+
+	// Priority of the handlers registered through the router.
+	priority int
+}
+
+// Registration is returned by the methods registering handlers. It is used to unregister the handler.
+type Registration struct {
+	bot   *Bot
+	entry *handlerEntry
+}
+
+// Unregister the handler. Unregistering the last handler of a command also removes it from the bot commands.
+// Return false if the handler was already unregistered.
+func (reg *Registration) Remove() bool {
+	return reg.bot.unregisterHandler(reg.entry)
+}
+
+// Group is a set of handlers sharing middlewares.
+type Group struct {
+	router
+}
+
+// Create a group of handlers. The middlewares of the group run around the handlers registered on the group,
+// after the global middlewares of the bot.
+func (b *Bot) Group(middlewares ...Middleware) *Group {
+	return b.router.group(middlewares)
+}
+
+// Create a group nested in the group g. Its handlers also run through the middlewares of g.
+func (g *Group) Group(middlewares ...Middleware) *Group {
+	return g.router.group(middlewares)
+}
+
+// Add middlewares to the group.
+func (g *Group) Use(middlewares ...Middleware) {
+
+	g.bot.registryMu.Lock()
+	defer g.bot.registryMu.Unlock()
+
+	g.middlewares = appendMiddlewares(g.middlewares, middlewares)
+}
+
+// Create a group whose handlers have the given priority. Matching handlers run by decreasing priority,
+// and handlers of the same priority run in registration order. The default priority is 0.
+func (r *router) Priority(priority int) *Group {
+
+	g := r.group(nil)
+	g.priority = priority
+
+	return g
+}
+
+// Create a group whose parent is r. It inherits the priority of r.
+func (r *router) group(middlewares []Middleware) *Group {
+	return &Group{router{bot: r.bot, parent: r, middlewares: middlewares, priority: r.priority}}
+}
+
+// Wrap the handler with the middlewares of the router and of its parents.
+// Middlewares are resolved when the handler runs, so that middlewares added later also apply.
+func (r *router) wrap(handler HandlerFunc) HandlerFunc {
+
+	// The root router has no middlewares of its own: global middlewares are applied on dispatch.
+	if r.parent == nil {
+		return handler
+	}
+
+	return func(c *Context) error {
+		r.bot.registryMu.RLock()
+		middlewares := r.middlewares
+		r.bot.registryMu.RUnlock()
+
+		return r.parent.wrap(chainMiddlewares(middlewares, handler))(c)
+	}
+}
+
+//
+// Below are defined the module API functions used to link handlers to events.
+//
+
+// Trigger handler if the text of the update matches the variable text.
+func (r *router) OnText(text string, handler HandlerFunc) *Registration {
+
+	event := ONTEXT
+
+	// Register handler.
+	entry := r.bot.eventHandler(event, text, r.wrap(handler), r.priority)
+	r.bot.registerHandler(entry)
+
+	return &Registration{r.bot, entry}
+}
+
+// Match commands (i.e. when text starts with the filter but can contain more text)
+func (r *router) OnCommand(text string, description string, handler HandlerFunc) *Registration {
+
+	event := ONCOMMAND
+
+	entry := r.bot.eventHandler(event, text, r.wrap(handler), r.priority)
+	entry.command = commandName(text)
+
+	// Register handler and the command in the bot commands.
+	r.bot.registerCommandHandler(entry, description)
+
+	return &Registration{r.bot, entry}
+}
+
+// Match CallbackQuery
+func (r *router) OnCallback(data string, handler HandlerFunc) *Registration {
+
+	event := ONCALLBACK
+
+	// Register handler.
+	entry := r.bot.eventHandler(event, data, r.wrap(handler), r.priority)
+	r.bot.registerHandler(entry)
+
+	return &Registration{r.bot, entry}
+}
+
+// Match CallbackQuery with payload
+func (r *router) OnPayload(data string, handler HandlerFunc) *Registration {
+
+	event := ONPAYLOAD
+
+	// Register handler.
+	entry := r.bot.eventHandler(event, data, r.wrap(handler), r.priority)
+	r.bot.registerHandler(entry)
+
+	return &Registration{r.bot, entry}
+}
+
+// Trigger handler if the text of the update (the text of the message or the data of the callback query)
+// matches the regular expression pattern. The submatches are available with Context.Matches.
+// OnRegex panics if pattern is not a valid regular expression.
+func (r *router) OnRegex(pattern string, handler HandlerFunc) *Registration {
+
+	re := regexp.MustCompile(pattern)
+
+	match := func(c *Context) bool {
+		matches := re.FindStringSubmatch(updateText(c.Update))
+
+		if matches == nil {
+			return false
+		}
+
+		c.regexp = re
+		c.matches = matches
+
+		return true
+	}
+
+	// Register handler.
+	entry := &handlerEntry{match: match, handler: r.wrap(handler), priority: r.priority}
+	r.bot.registerHandler(entry)
+
+	return &Registration{r.bot, entry}
+}
+
+// Trigger handler for every update matching predicate.
+func (r *router) OnFunc(predicate Filter, handler HandlerFunc) *Registration {
+
+	match := func(c *Context) bool {
+		return predicate(c.Update)
+	}
+
+	// Register handler.
+	entry := &handlerEntry{match: match, handler: r.wrap(handler), priority: r.priority}
+	r.bot.registerHandler(entry)
+
+	return &Registration{r.bot, entry}
+}
+
+// Trigger handler for the updates matched by no other handler. It replaces the previous default handler.
+func (r *router) OnDefault(handler HandlerFunc) *Registration {
+
+	entry := &handlerEntry{handler: r.wrap(handler)}
+	r.bot.setDefaultHandler(entry)
+
+	return &Registration{r.bot, entry}
+}
+
+//
+// Below are defined the functions linking handlers to the kinds of updates.
+//
+
+// Trigger handler for every new message, whatever its content.
+func (r *router) OnMessage(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.Message != nil }, handler)
+}
+
+// Trigger handler for every edited message.
+func (r *router) OnEditedMessage(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.EditedMessage != nil }, handler)
+}
+
+// Trigger handler for every new post in a channel.
+func (r *router) OnChannelPost(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.ChannelPost != nil }, handler)
+}
+
+// Trigger handler for every edited post in a channel.
+func (r *router) OnEditedChannelPost(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.EditedChannelPost != nil }, handler)
+}
+
+// Trigger handler for every inline query.
+func (r *router) OnInlineQuery(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.InlineQuery != nil }, handler)
+}
+
+// Trigger handler for every inline result chosen by a user.
+func (r *router) OnChosenInlineResult(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.ChosenInlineResult != nil }, handler)
+}
+
+// Trigger handler for every shipping query of an invoice with flexible price.
+func (r *router) OnShippingQuery(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.ShippingQuery != nil }, handler)
+}
+
+// Trigger handler for every pre-checkout query.
+func (r *router) OnPreCheckoutQuery(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.PreCheckoutQuery != nil }, handler)
+}
+
+// Trigger handler for every new state of a poll.
+func (r *router) OnPoll(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.Poll != nil }, handler)
+}
+
+// Trigger handler for every answer changed in a non anonymous poll.
+func (r *router) OnPollAnswer(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.PollAnswer != nil }, handler)
+}
+
+// Trigger handler for every change of the status of the bot in a chat.
+func (r *router) OnMyChatMember(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.MyChatMember != nil }, handler)
+}
+
+// Trigger handler for every change of the status of a member in a chat.
+func (r *router) OnChatMember(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.ChatMember != nil }, handler)
+}
+
+// Trigger handler for every request to join a chat.
+func (r *router) OnChatJoinRequest(handler HandlerFunc) *Registration {
+	return r.OnFunc(func(u *Update) bool { return u.ChatJoinRequest != nil }, handler)
+}
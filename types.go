@@ -1,11 +1,87 @@
 package telebot
 
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
 // Bot object definition.
 type Bot struct {
-	apiToken   string
-	config     map[string]string
-	handlerMap map[string]map[string]func(u *Update)
-	commands   []BotCommand
+	apiToken string
+	username string
+	mode     Mode
+	webhook  WebhookConfig
+
+	// Registry of handlers, commands and middlewares, which can change while the bot runs.
+	// Slices are copied on write so that readers can keep them after releasing registryMu.
+	// Handlers are sorted by decreasing priority, then in registration order.
+	registryMu     sync.RWMutex
+	commands       []BotCommand
+	syncCommands   chan struct{}
+	handlers       []*handlerEntry
+	defaultHandler *handlerEntry
+
+	// Handlers registered on the bot and global middlewares.
+	router
+	globalMiddlewares []Middleware
+	errorHandler      func(c *Context, err error)
+
+	httpClient     *http.Client
+	baseUrl        string
+	localAPIServer bool
+	fileCache      FileCache
+	retryPolicy    RetryPolicy
+	limiter        *rateLimiter
+
+	pollTimeout    time.Duration
+	pollLimit      int
+	allowedUpdates []string
+
+	// Offset of the next getUpdates call. It is kept across restarts of the bot,
+	// so that the updates handled before Stop are confirmed to Telegram by the next poll.
+	pollOffset int
+
+	// Lifecycle of the bot.
+	shutdownTimeout time.Duration
+	mu              sync.Mutex
+	cancelSetUp     context.CancelFunc
+	setUpDone       chan struct{}
+	cancel          context.CancelFunc
+	done            chan struct{}
+	runErr          error
+
+	// Webhook server.
+	listenAddr      string
+	plainHTTP       bool
+	webhookServer   *http.Server
+	noWebhookServer bool
+	secretToken     string
+	maxBodySize     int64
+	sourceAllowlist []*net.IPNet
+
+	// Webhook settings.
+	webhookCertificate string
+	maxConnections     int
+	dropPendingUpdates bool
+
+	// Dispatching of updates.
+	workers    int
+	queueSize  int
+	dispatcher *dispatcher
+}
+
+// Handler triggered for the updates matched by match.
+type handlerEntry struct {
+	match    func(c *Context) bool
+	handler  HandlerFunc
+	priority int
+
+	// Name of the command of the handlers registered with OnCommand.
+	command string
 }
 
 // Paths to SSL certificate .key and .crt file
@@ -20,10 +96,13 @@ type Event struct {
 	Checker    func(toCheck string, filter string) bool
 }
 
-// Structure of the /getUpdates response body.
-type GetUpdateResponse struct {
-	Ok     bool     `json:"ok"`
-	Result []Update `json:"result"`
+// Structure of the Telegram API response body.
+type APIResponse struct {
+	Ok          bool                `json:"ok"`
+	Result      json.RawMessage     `json:"result"`
+	ErrorCode   int                 `json:"error_code"`
+	Description string              `json:"description"`
+	Parameters  *ResponseParameters `json:"parameters"`
 }
 
 //
@@ -32,15 +111,82 @@ type GetUpdateResponse struct {
 
 // Chat type corresponding to the interesting part of the Chat Object in the Telegram API.
 type Chat struct {
-	Id int `json:"id"`
+	Id        int64  `json:"id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	IsForum   bool   `json:"is_forum"`
 }
 
 // Message type corresponding to the interesting part of the Message Object in the Telegram API.
 type Message struct {
-	Id   int    `json:"message_id"`
-	Text string `json:"text"`
-	From User   `json:"from"`
-	Chat Chat   `json:"chat"`
+	Id              int    `json:"message_id"`
+	MessageThreadId int    `json:"message_thread_id"`
+	From            *User  `json:"from"`
+	SenderChat      *Chat  `json:"sender_chat"`
+	Date            int64  `json:"date"`
+	Chat            Chat   `json:"chat"`
+	ViaBot          *User  `json:"via_bot"`
+	EditDate        int64  `json:"edit_date"`
+	MediaGroupId    string `json:"media_group_id"`
+	AuthorSignature string `json:"author_signature"`
+
+	// Forwarded messages.
+	ForwardFrom          *User  `json:"forward_from"`
+	ForwardFromChat      *Chat  `json:"forward_from_chat"`
+	ForwardFromMessageId int    `json:"forward_from_message_id"`
+	ForwardSignature     string `json:"forward_signature"`
+	ForwardSenderName    string `json:"forward_sender_name"`
+	ForwardDate          int64  `json:"forward_date"`
+	IsAutomaticForward   bool   `json:"is_automatic_forward"`
+
+	// Replies.
+	ReplyToMessage *Message              `json:"reply_to_message"`
+	ReplyMarkup    *InlineKeyboardMarkup `json:"reply_markup"`
+
+	// Content.
+	Text            string          `json:"text"`
+	Entities        []MessageEntity `json:"entities"`
+	Caption         string          `json:"caption"`
+	CaptionEntities []MessageEntity `json:"caption_entities"`
+	Animation       *Animation      `json:"animation"`
+	Audio           *Audio          `json:"audio"`
+	Document        *Document       `json:"document"`
+	Photo           []PhotoSize     `json:"photo"`
+	Sticker         *Sticker        `json:"sticker"`
+	Video           *Video          `json:"video"`
+	VideoNote       *VideoNote      `json:"video_note"`
+	Voice           *Voice          `json:"voice"`
+	Contact         *Contact        `json:"contact"`
+	Dice            *Dice           `json:"dice"`
+	Poll            *Poll           `json:"poll"`
+	Venue           *Venue          `json:"venue"`
+	Location        *Location       `json:"location"`
+
+	// Service messages.
+	NewChatMembers        []User      `json:"new_chat_members"`
+	LeftChatMember        *User       `json:"left_chat_member"`
+	NewChatTitle          string      `json:"new_chat_title"`
+	NewChatPhoto          []PhotoSize `json:"new_chat_photo"`
+	DeleteChatPhoto       bool        `json:"delete_chat_photo"`
+	GroupChatCreated      bool        `json:"group_chat_created"`
+	SupergroupChatCreated bool        `json:"supergroup_chat_created"`
+	ChannelChatCreated    bool        `json:"channel_chat_created"`
+	MigrateToChatId       int64       `json:"migrate_to_chat_id"`
+	MigrateFromChatId     int64       `json:"migrate_from_chat_id"`
+	PinnedMessage         *Message    `json:"pinned_message"`
+}
+
+// MessageEntity type corresponding to the MessageEntity Object in the Telegram API.
+type MessageEntity struct {
+	Type     string `json:"type"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	Url      string `json:"url"`
+	User     *User  `json:"user"`
+	Language string `json:"language"`
 }
 
 // Option type for the sendMessage API
@@ -52,17 +198,94 @@ type SendMessageOptions struct {
 	AllowSendingWithoutReply bool
 }
 
-// Update type corresponding to the interesting part of the Update Object in the Telegram API.
+// InputMedia is an item of a media group. Type is "photo", "video", "document" or "audio".
+// The options not supported by the type of the item are ignored.
+type InputMedia struct {
+	Type      string
+	Media     InputFile
+	Caption   string
+	ParseMode string
+
+	// Thumbnail of videos, documents and audios.
+	Thumb *InputFile
+
+	// Metadata of videos and audios.
+	Width             int
+	Height            int
+	Duration          int
+	SupportsStreaming bool
+	Performer         string
+	Title             string
+
+	// Always send documents as documents, even if Telegram detects their type.
+	DisableContentTypeDetection bool
+}
+
+// Options of the methods sending files. The options not supported by a method are ignored.
+type SendMediaOptions struct {
+	SendMessageOptions
+
+	Caption string
+
+	// Thumbnail of the file, uploaded as a JPEG of at most 200 kB and 320x320 (documents, audios, videos and animations).
+	Thumb *InputFile
+
+	// Duration in seconds (audios, videos, voices, video notes and animations).
+	Duration int
+
+	// Size of videos and animations, and diameter of video notes.
+	Width  int
+	Height int
+	Length int
+
+	// Audio metadata.
+	Performer string
+	Title     string
+
+	// Videos suitable for streaming.
+	SupportsStreaming bool
+
+	// Always send documents as documents, even if Telegram detects their type.
+	DisableContentTypeDetection bool
+
+	// Report the progress of the upload of the files.
+	Progress ProgressFunc
+}
+
+// Update type corresponding to the Update Object in the Telegram API.
+// At most one of the optional fields is set.
 type Update struct {
-	UpdateId      int           `json:"update_id"`
-	Message       Message       `json:"message"`
-	CallbackQuery CallbackQuery `json:"callback_query"`
+	UpdateId           int                 `json:"update_id"`
+	Message            *Message            `json:"message"`
+	EditedMessage      *Message            `json:"edited_message"`
+	ChannelPost        *Message            `json:"channel_post"`
+	EditedChannelPost  *Message            `json:"edited_channel_post"`
+	InlineQuery        *InlineQuery        `json:"inline_query"`
+	ChosenInlineResult *ChosenInlineResult `json:"chosen_inline_result"`
+	CallbackQuery      *CallbackQuery      `json:"callback_query"`
+	ShippingQuery      *ShippingQuery      `json:"shipping_query"`
+	PreCheckoutQuery   *PreCheckoutQuery   `json:"pre_checkout_query"`
+	Poll               *Poll               `json:"poll"`
+	PollAnswer         *PollAnswer         `json:"poll_answer"`
+	MyChatMember       *ChatMemberUpdated  `json:"my_chat_member"`
+	ChatMember         *ChatMemberUpdated  `json:"chat_member"`
+	ChatJoinRequest    *ChatJoinRequest    `json:"chat_join_request"`
+}
+
+// ResponseParameters type corresponding to the ResponseParameters Object in the Telegram API.
+type ResponseParameters struct {
+	MigrateToChatId int64 `json:"migrate_to_chat_id"`
+	RetryAfter      int   `json:"retry_after"`
 }
 
 // User type corresponding to the interesting part of the User Object in the Telegram API.
 type User struct {
-	Id       int    `json:"id"`
-	Username string `json:"username"`
+	Id           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
 }
 
 type ReplyKeyboardMarkup struct {
@@ -91,10 +314,305 @@ type InlineKeyboardButton struct {
 }
 
 type CallbackQuery struct {
-	Id      string  `json:"id"`
-	From    User    `json:"from"`
-	Message Message `json:"message"`
-	Data    string  `json:"data"`
+	Id              string   `json:"id"`
+	From            User     `json:"from"`
+	Message         *Message `json:"message"`
+	InlineMessageId string   `json:"inline_message_id"`
+	ChatInstance    string   `json:"chat_instance"`
+	Data            string   `json:"data"`
+	GameShortName   string   `json:"game_short_name"`
+}
+
+// InlineQuery type corresponding to the InlineQuery Object in the Telegram API.
+type InlineQuery struct {
+	Id       string    `json:"id"`
+	From     User      `json:"from"`
+	Query    string    `json:"query"`
+	Offset   string    `json:"offset"`
+	ChatType string    `json:"chat_type"`
+	Location *Location `json:"location"`
+}
+
+// ChosenInlineResult type corresponding to the ChosenInlineResult Object in the Telegram API.
+type ChosenInlineResult struct {
+	ResultId        string    `json:"result_id"`
+	From            User      `json:"from"`
+	Location        *Location `json:"location"`
+	InlineMessageId string    `json:"inline_message_id"`
+	Query           string    `json:"query"`
+}
+
+// PhotoSize type corresponding to the PhotoSize Object in the Telegram API.
+type PhotoSize struct {
+	FileId       string `json:"file_id"`
+	FileUniqueId string `json:"file_unique_id"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FileSize     int64  `json:"file_size"`
+}
+
+// Animation type corresponding to the Animation Object in the Telegram API.
+type Animation struct {
+	FileId       string     `json:"file_id"`
+	FileUniqueId string     `json:"file_unique_id"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	Duration     int        `json:"duration"`
+	Thumb        *PhotoSize `json:"thumb"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	FileSize     int64      `json:"file_size"`
+}
+
+// Audio type corresponding to the Audio Object in the Telegram API.
+type Audio struct {
+	FileId       string     `json:"file_id"`
+	FileUniqueId string     `json:"file_unique_id"`
+	Duration     int        `json:"duration"`
+	Performer    string     `json:"performer"`
+	Title        string     `json:"title"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	FileSize     int64      `json:"file_size"`
+	Thumb        *PhotoSize `json:"thumb"`
+}
+
+// Document type corresponding to the Document Object in the Telegram API.
+type Document struct {
+	FileId       string     `json:"file_id"`
+	FileUniqueId string     `json:"file_unique_id"`
+	Thumb        *PhotoSize `json:"thumb"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	FileSize     int64      `json:"file_size"`
+}
+
+// Video type corresponding to the Video Object in the Telegram API.
+type Video struct {
+	FileId       string     `json:"file_id"`
+	FileUniqueId string     `json:"file_unique_id"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	Duration     int        `json:"duration"`
+	Thumb        *PhotoSize `json:"thumb"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	FileSize     int64      `json:"file_size"`
+}
+
+// VideoNote type corresponding to the VideoNote Object in the Telegram API.
+type VideoNote struct {
+	FileId       string     `json:"file_id"`
+	FileUniqueId string     `json:"file_unique_id"`
+	Length       int        `json:"length"`
+	Duration     int        `json:"duration"`
+	Thumb        *PhotoSize `json:"thumb"`
+	FileSize     int64      `json:"file_size"`
+}
+
+// Voice type corresponding to the Voice Object in the Telegram API.
+type Voice struct {
+	FileId       string `json:"file_id"`
+	FileUniqueId string `json:"file_unique_id"`
+	Duration     int    `json:"duration"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+// Sticker type corresponding to the Sticker Object in the Telegram API.
+type Sticker struct {
+	FileId       string     `json:"file_id"`
+	FileUniqueId string     `json:"file_unique_id"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	IsAnimated   bool       `json:"is_animated"`
+	IsVideo      bool       `json:"is_video"`
+	Thumb        *PhotoSize `json:"thumb"`
+	Emoji        string     `json:"emoji"`
+	SetName      string     `json:"set_name"`
+	FileSize     int64      `json:"file_size"`
+}
+
+// Contact type corresponding to the Contact Object in the Telegram API.
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	UserId      int64  `json:"user_id"`
+	Vcard       string `json:"vcard"`
+}
+
+// Dice type corresponding to the Dice Object in the Telegram API.
+type Dice struct {
+	Emoji string `json:"emoji"`
+	Value int    `json:"value"`
+}
+
+// Venue type corresponding to the Venue Object in the Telegram API.
+type Venue struct {
+	Location       Location `json:"location"`
+	Title          string   `json:"title"`
+	Address        string   `json:"address"`
+	FoursquareId   string   `json:"foursquare_id"`
+	FoursquareType string   `json:"foursquare_type"`
+}
+
+// Location type corresponding to the Location Object in the Telegram API.
+type Location struct {
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+}
+
+// ShippingQuery type corresponding to the ShippingQuery Object in the Telegram API.
+type ShippingQuery struct {
+	Id              string          `json:"id"`
+	From            User            `json:"from"`
+	InvoicePayload  string          `json:"invoice_payload"`
+	ShippingAddress ShippingAddress `json:"shipping_address"`
+}
+
+// ShippingAddress type corresponding to the ShippingAddress Object in the Telegram API.
+type ShippingAddress struct {
+	CountryCode string `json:"country_code"`
+	State       string `json:"state"`
+	City        string `json:"city"`
+	StreetLine1 string `json:"street_line1"`
+	StreetLine2 string `json:"street_line2"`
+	PostCode    string `json:"post_code"`
+}
+
+// PreCheckoutQuery type corresponding to the PreCheckoutQuery Object in the Telegram API.
+type PreCheckoutQuery struct {
+	Id               string     `json:"id"`
+	From             User       `json:"from"`
+	Currency         string     `json:"currency"`
+	TotalAmount      int        `json:"total_amount"`
+	InvoicePayload   string     `json:"invoice_payload"`
+	ShippingOptionId string     `json:"shipping_option_id"`
+	OrderInfo        *OrderInfo `json:"order_info"`
+}
+
+// OrderInfo type corresponding to the OrderInfo Object in the Telegram API.
+type OrderInfo struct {
+	Name            string           `json:"name"`
+	PhoneNumber     string           `json:"phone_number"`
+	Email           string           `json:"email"`
+	ShippingAddress *ShippingAddress `json:"shipping_address"`
+}
+
+// Poll type corresponding to the Poll Object in the Telegram API.
+type Poll struct {
+	Id                    string          `json:"id"`
+	Question              string          `json:"question"`
+	Options               []PollOption    `json:"options"`
+	TotalVoterCount       int             `json:"total_voter_count"`
+	IsClosed              bool            `json:"is_closed"`
+	IsAnonymous           bool            `json:"is_anonymous"`
+	Type                  string          `json:"type"`
+	AllowsMultipleAnswers bool            `json:"allows_multiple_answers"`
+	CorrectOptionId       *int            `json:"correct_option_id"`
+	Explanation           string          `json:"explanation"`
+	ExplanationEntities   []MessageEntity `json:"explanation_entities"`
+	OpenPeriod            int             `json:"open_period"`
+	CloseDate             int64           `json:"close_date"`
+}
+
+// PollOption type corresponding to the PollOption Object in the Telegram API.
+type PollOption struct {
+	Text       string `json:"text"`
+	VoterCount int    `json:"voter_count"`
+}
+
+// PollAnswer type corresponding to the PollAnswer Object in the Telegram API.
+type PollAnswer struct {
+	PollId    string `json:"poll_id"`
+	User      User   `json:"user"`
+	OptionIds []int  `json:"option_ids"`
+}
+
+// ChatMemberUpdated type corresponding to the ChatMemberUpdated Object in the Telegram API.
+type ChatMemberUpdated struct {
+	Chat          Chat            `json:"chat"`
+	From          User            `json:"from"`
+	Date          int64           `json:"date"`
+	OldChatMember ChatMember      `json:"old_chat_member"`
+	NewChatMember ChatMember      `json:"new_chat_member"`
+	InviteLink    *ChatInviteLink `json:"invite_link"`
+}
+
+// ChatMember type corresponding to the ChatMember Object in the Telegram API.
+// Status is one of "creator", "administrator", "member", "restricted", "left" or "kicked",
+// and only the fields of this status are set.
+type ChatMember struct {
+	Status      string `json:"status"`
+	User        User   `json:"user"`
+	IsAnonymous bool   `json:"is_anonymous"`
+	CustomTitle string `json:"custom_title"`
+	IsMember    bool   `json:"is_member"`
+	UntilDate   int64  `json:"until_date"`
+
+	// Rights of administrators.
+	CanBeEdited         bool `json:"can_be_edited"`
+	CanManageChat       bool `json:"can_manage_chat"`
+	CanDeleteMessages   bool `json:"can_delete_messages"`
+	CanManageVideoChats bool `json:"can_manage_video_chats"`
+	CanRestrictMembers  bool `json:"can_restrict_members"`
+	CanPromoteMembers   bool `json:"can_promote_members"`
+	CanChangeInfo       bool `json:"can_change_info"`
+	CanInviteUsers      bool `json:"can_invite_users"`
+	CanPostMessages     bool `json:"can_post_messages"`
+	CanEditMessages     bool `json:"can_edit_messages"`
+	CanPinMessages      bool `json:"can_pin_messages"`
+
+	// Permissions of restricted members.
+	CanSendMessages       bool `json:"can_send_messages"`
+	CanSendMediaMessages  bool `json:"can_send_media_messages"`
+	CanSendPolls          bool `json:"can_send_polls"`
+	CanSendOtherMessages  bool `json:"can_send_other_messages"`
+	CanAddWebPagePreviews bool `json:"can_add_web_page_previews"`
+}
+
+// ChatInviteLink type corresponding to the ChatInviteLink Object in the Telegram API.
+type ChatInviteLink struct {
+	InviteLink              string `json:"invite_link"`
+	Creator                 User   `json:"creator"`
+	CreatesJoinRequest      bool   `json:"creates_join_request"`
+	IsPrimary               bool   `json:"is_primary"`
+	IsRevoked               bool   `json:"is_revoked"`
+	Name                    string `json:"name"`
+	ExpireDate              int64  `json:"expire_date"`
+	MemberLimit             int    `json:"member_limit"`
+	PendingJoinRequestCount int    `json:"pending_join_request_count"`
+}
+
+// ChatJoinRequest type corresponding to the ChatJoinRequest Object in the Telegram API.
+type ChatJoinRequest struct {
+	Chat       Chat            `json:"chat"`
+	From       User            `json:"from"`
+	Date       int64           `json:"date"`
+	Bio        string          `json:"bio"`
+	InviteLink *ChatInviteLink `json:"invite_link"`
+}
+
+// File type corresponding to the File Object in the Telegram API. Download it with Bot.DownloadFile.
+type File struct {
+	FileId       string `json:"file_id"`
+	FileUniqueId string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size"`
+	FilePath     string `json:"file_path"`
+}
+
+// WebhookInfo type corresponding to the WebhookInfo Object in the Telegram API.
+type WebhookInfo struct {
+	Url                          string   `json:"url"`
+	HasCustomCertificate         bool     `json:"has_custom_certificate"`
+	PendingUpdateCount           int      `json:"pending_update_count"`
+	IpAddress                    string   `json:"ip_address"`
+	LastErrorDate                int64    `json:"last_error_date"`
+	LastErrorMessage             string   `json:"last_error_message"`
+	LastSynchronizationErrorDate int64    `json:"last_synchronization_error_date"`
+	MaxConnections               int      `json:"max_connections"`
+	AllowedUpdates               []string `json:"allowed_updates"`
 }
 
 type BotCommand struct {
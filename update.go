@@ -0,0 +1,115 @@
+package telebot
+
+// Return the message of the update: the message received, edited or posted in a channel,
+// or the message of the callback query. Return nil if there is none.
+func updateMessage(u *Update) *Message {
+
+	switch {
+	case u.Message != nil:
+		return u.Message
+	case u.EditedMessage != nil:
+		return u.EditedMessage
+	case u.ChannelPost != nil:
+		return u.ChannelPost
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.Message
+	}
+
+	return nil
+}
+
+// Return the chat the update comes from, or nil if there is none.
+func updateChat(u *Update) *Chat {
+
+	switch {
+	case u.MyChatMember != nil:
+		return &u.MyChatMember.Chat
+	case u.ChatMember != nil:
+		return &u.ChatMember.Chat
+	case u.ChatJoinRequest != nil:
+		return &u.ChatJoinRequest.Chat
+	}
+
+	if message := updateMessage(u); message != nil && message.Chat.Id != 0 {
+		return &message.Chat
+	}
+
+	return nil
+}
+
+// Return the user who sent the update, or nil if unknown.
+func updateSender(u *Update) *User {
+
+	var user *User
+
+	switch {
+	case u.CallbackQuery != nil:
+		user = &u.CallbackQuery.From
+	case u.InlineQuery != nil:
+		user = &u.InlineQuery.From
+	case u.ChosenInlineResult != nil:
+		user = &u.ChosenInlineResult.From
+	case u.ShippingQuery != nil:
+		user = &u.ShippingQuery.From
+	case u.PreCheckoutQuery != nil:
+		user = &u.PreCheckoutQuery.From
+	case u.PollAnswer != nil:
+		user = &u.PollAnswer.User
+	case u.MyChatMember != nil:
+		user = &u.MyChatMember.From
+	case u.ChatMember != nil:
+		user = &u.ChatMember.From
+	case u.ChatJoinRequest != nil:
+		user = &u.ChatJoinRequest.From
+	default:
+		// Channel posts have no sender.
+		if message := updateMessage(u); message != nil {
+			user = message.From
+		}
+	}
+
+	if user == nil || user.Id == 0 {
+		return nil
+	}
+
+	return user
+}
+
+// Return the text of the update: the text of the message, or the data of the callback query.
+func updateText(u *Update) string {
+
+	switch {
+	case u.Message != nil:
+		return u.Message.Text
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.Data
+	}
+
+	return ""
+}
+
+// Return the id of the user who sent the update, 0 if unknown.
+func updateSenderId(u *Update) int64 {
+
+	if user := updateSender(u); user != nil {
+		return user.Id
+	}
+
+	return 0
+}
+
+// Return the id used to order updates: the chat of the update, or its sender when there is no chat.
+func updateChatId(u *Update) int64 {
+
+	if chat := updateChat(u); chat != nil {
+		return chat.Id
+	}
+
+	if senderId := updateSenderId(u); senderId != 0 {
+		return senderId
+	}
+
+	return int64(u.UpdateId)
+}
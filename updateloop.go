@@ -1,73 +1,120 @@
 package telebot
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 )
 
-// Parse Telegram API response body to get the slice of updates.
-func parseTelegramGetUpdateResponse(r *http.Response) ([]Update, error) {
+// Fetch and dispatch updates with long polling until ctx is done.
+func (b *Bot) pollUpdates(ctx context.Context) {
 
-	var getUpdateResponse GetUpdateResponse
+	// Number of consecutive failed polls.
+	failures := 0
 
-	// Try to parse responde body.
-	if err := json.NewDecoder(r.Body).Decode(&getUpdateResponse); err != nil {
-		log.Printf("Could not decode incoming response %s", err.Error())
-		return nil, err
+	for ctx.Err() == nil {
+
+		// Get and dispatch updates. Set a new offset.
+		newOffset, err := b.getUpdates(ctx, b.pollOffset)
+
+		// Updates queued before an interruption are not fetched again.
+		b.pollOffset = newOffset
+
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		failures++
+		delay := pollBackoff(failures, err)
+
+		log.Printf("Error fetching updates, retrying in %s: %s", delay, err.Error())
+		sleepCtx(ctx, delay)
 	}
+}
 
-	if !getUpdateResponse.Ok {
-		log.Printf("Telegram response was not ok")
-		return nil, errors.New("telegram response was not ok")
+// Delay before the next poll after failures consecutive errors, the last one being err.
+func pollBackoff(failures int, err error) time.Duration {
+
+	// Flood control: wait as long as Telegram asks to.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode == http.StatusTooManyRequests && apiErr.Parameters != nil && apiErr.Parameters.RetryAfter > 0 {
+		return time.Duration(apiErr.Parameters.RetryAfter) * time.Second
 	}
 
-	return getUpdateResponse.Result, nil
+	policy := RetryPolicy{BaseDelay: pollMinBackoff, MaxDelay: pollMaxBackoff}
+
+	return policy.backoff(failures)
 }
 
-//Fetch and dispatch last updates for a Bot with Telegram /getUpdates API endpoint.
-func (b *Bot) getUpdates(offset int) int {
+// Fetch and dispatch last updates for a Bot with Telegram /getUpdates API endpoint.
+// Return the offset of the next call.
+func (b *Bot) getUpdates(ctx context.Context, offset int) (int, error) {
 
-	// Get Updates with Telegram /getUpdates API.
-	res, err := http.PostForm(
-		telegramApiBaseUrl+b.apiToken+getUpdatesEndpoint,
-		url.Values{
-			"offset": {strconv.Itoa(offset)},
-		},
-	)
-
-	// In case of connection error.
-	if err != nil {
-		log.Printf("Error fetching updates: %s", err.Error())
-		return offset
+	val := url.Values{
+		"offset":  {strconv.Itoa(offset)},
+		"timeout": {strconv.Itoa(int(b.pollTimeout / time.Second))},
+	}
+
+	// Maximum number of updates fetched at once.
+	if b.pollLimit > 0 {
+		val["limit"] = []string{strconv.Itoa(b.pollLimit)}
 	}
 
-	defer res.Body.Close()
+	// Types of updates to receive.
+	if err := b.setAllowedUpdates(val); err != nil {
+		return offset, err
+	}
 
-	// Parse Telegram response.
-	updates, err := parseTelegramGetUpdateResponse(res)
+	var updates []Update
 
-	// Handle parsing errors.
-	if err != nil {
-		log.Printf("Error parsing update, %s", err.Error())
-		return offset
+	// Get Updates with Telegram /getUpdates API.
+	// The poll loop has its own backoff so the call is not retried.
+	if err := b.doAPICall(ctx, getUpdatesEndpoint, val, &updates); err != nil {
+		return offset, err
 	}
 
-	// Dispatch fetched updates to handlers.
-	for _, update := range updates {
-		b.dispatchUpdate(&update)
+	// Queue fetched updates in the dispatcher. The loop blocks while the queues are full.
+	for i := range updates {
+		if !b.enqueueUpdate(ctx, &updates[i]) {
+			return updates[i].UpdateId, ctx.Err()
+		}
 	}
 
 	// If Updates were received, we must update offset
 	if len(updates) > 0 {
 		newOffset := updates[len(updates)-1].UpdateId + 1
-		return newOffset
+		return newOffset, nil
 	}
 
 	// If not offset stay the same
-	return offset
+	return offset, nil
+
+}
+
+// Add the types of updates the bot receives to the parameters of a getUpdates or setWebhook call.
+func (b *Bot) setAllowedUpdates(val url.Values) error {
+
+	if b.allowedUpdates == nil {
+		return nil
+	}
+
+	jsonAllowedUpdates, err := json.Marshal(b.allowedUpdates)
+
+	if err != nil {
+		return err
+	}
+
+	val["allowed_updates"] = []string{string(jsonAllowedUpdates)}
 
+	return nil
 }
@@ -1,34 +1,362 @@
 package telebot
 
 import (
-	"io/ioutil"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"log"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-// Helper to call Telegram API on the endpoint passed as parameter
-func (b *Bot) makeAPICall(endpoint string, v url.Values) (string, error) {
-	// Try to send message with telegram API /sendMessage endpoint.
-	response, err := http.PostForm(
-		telegramApiBaseUrl+b.apiToken+endpoint,
-		v,
-	)
+// Helper to call Telegram API on the endpoint passed as parameter.
+// The result field of the response is decoded in result, unless result is nil.
+func (b *Bot) makeAPICall(ctx context.Context, endpoint string, v url.Values, result interface{}) error {
+
+	return b.retryAPICall(ctx, endpoint, v, func(v url.Values) error {
+		return b.doAPICall(ctx, endpoint, v, result)
+	})
+}
+
+// Helper to call Telegram API with a multipart/form-data body uploading files.
+// files maps the name of the fields to the files to upload. progress, if not nil, reports the upload of the files.
+func (b *Bot) makeMultipartAPICall(ctx context.Context, endpoint string, v url.Values, files map[string]*InputFile, progress ProgressFunc, result interface{}) error {
+
+	var lastErr error
+
+	return b.retryAPICall(ctx, endpoint, v, func(v url.Values) error {
+
+		// Readers consumed by a failed attempt are read again from the start.
+		for _, file := range files {
+			if err := file.rewind(); err != nil {
+
+				// The content could not be located before the first attempt.
+				if lastErr == nil {
+					return err
+				}
+
+				return &rewindError{err: err, lastErr: lastErr}
+			}
+		}
+
+		lastErr = b.doMultipartAPICall(ctx, endpoint, v, files, progress, result)
+
+		return lastErr
+	})
+}
+
+// Run call, which makes a single call to the endpoint with the parameters it is passed, starting with v.
+// Calls are queued by the rate limiter and failed calls are retried according to the retry policy of the bot.
+// v is copied, so that the parameters of the caller are not changed when a call is resent to a migrated chat.
+func (b *Bot) retryAPICall(ctx context.Context, endpoint string, v url.Values, call func(v url.Values) error) error {
+
+	policy := b.retryPolicy
+	v = cloneValues(v)
+
+	for attempt := 1; ; attempt++ {
+
+		// Messages sent to a chat are throttled to respect Telegram limits.
+		if chatId := v.Get("chat_id"); chatId != "" && isSendEndpoint(endpoint) {
+			if err := b.limiter.wait(ctx, chatId, messageCount(endpoint, v)); err != nil {
+				return err
+			}
+		}
+
+		err := call(v)
+
+		// Never retry a call whose context is done.
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		// The group was migrated to a supergroup: resend the call to the supergroup.
+		if newChatId := migratedChatId(err); newChatId != 0 && policy.FollowMigrations && v.Get("chat_id") != "" {
+			log.Printf("Chat %s migrated to %d, retrying call to %s", v.Get("chat_id"), newChatId, endpoint)
+			v.Set("chat_id", strconv.FormatInt(newChatId, 10))
+			continue
+		}
+
+		delay, retry := policy.retryDelay(attempt, err)
+
+		if !retry {
+			return err
+		}
+
+		log.Printf("Retrying call to %s in %s: %s", endpoint, delay, err.Error())
+
+		if err := sleepCtx(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Make a single call to the Telegram API endpoint with url encoded parameters.
+func (b *Bot) doAPICall(ctx context.Context, endpoint string, v url.Values, result interface{}) error {
+
+	return b.sendAPIRequest(ctx, endpoint, strings.NewReader(v.Encode()), "application/x-www-form-urlencoded", result)
+}
+
+// Make a single call to the Telegram API endpoint with a multipart/form-data body.
+// The body is streamed to the request while it is written, so that files are never loaded in memory.
+func (b *Bot) doMultipartAPICall(ctx context.Context, endpoint string, v url.Values, files map[string]*InputFile, progress ProgressFunc, result interface{}) error {
+
+	body, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+
+	var writeErr error
+	written := make(chan struct{})
+
+	go func() {
+		defer close(written)
+
+		writeErr = writeMultipartBody(writer, v, files, progress)
+		pipeWriter.CloseWithError(writeErr)
+	}()
+
+	// The HTTP client waits for the body to be read before returning a cancelled request:
+	// close the body when ctx is done, as the writer may be blocked reading a file.
+	requestDone := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			body.CloseWithError(ctx.Err())
+		case <-requestDone:
+		}
+	}()
+
+	err := b.sendAPIRequest(ctx, endpoint, body, writer.FormDataContentType(), result)
+	close(requestDone)
+
+	// Stop writing if the request ended before the whole body was sent.
+	body.CloseWithError(errUploadAborted)
+
+	// A cancelled call returns without waiting for a reader blocked in Read: the writer exits on its next write.
+	// The call is not retried, so the files are not read again meanwhile.
+	// The request may fail on the closed body before noticing that ctx is done: report ctx.
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// Wait for the files to be closed.
+	<-written
+
+	// Errors reading the files are not retried. Writes fail with a closed pipe when the request ended first.
+	if writeErr != nil && !errors.Is(writeErr, errUploadAborted) && !errors.Is(writeErr, io.ErrClosedPipe) {
+		return writeErr
+	}
+
+	return err
+}
+
+// Write the parameters v and the files of a multipart/form-data body.
+func writeMultipartBody(writer *multipart.Writer, v url.Values, files map[string]*InputFile, progress ProgressFunc) error {
+
+	// Parameters.
+	for key, values := range v {
+		for _, value := range values {
+			if err := writer.WriteField(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Files, in a stable order.
+	fields := make([]string, 0, len(files))
+	for field := range files {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var counter *progressCounter
+
+	if progress != nil {
+		counter = &progressCounter{progress: progress, total: uploadSize(files)}
+	}
+
+	for _, field := range fields {
+		if err := files[field].writeMultipart(writer, field, counter); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// Send a request to the Telegram API endpoint and decode the response.
+func (b *Bot) sendAPIRequest(ctx context.Context, endpoint string, body io.Reader, contentType string, result interface{}) error {
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpointUrl(endpoint), body)
 
 	if err != nil {
-		log.Printf("Error when posting text to the chat: %s", err.Error())
-		return "", err
+		return err
 	}
 
-	defer response.Body.Close()
+	request.Header.Set("Content-Type", contentType)
+
+	// Call the endpoint.
+	response, err := b.httpClient.Do(request)
 
-	bodyBytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
+		log.Printf("Error when calling Telegram API endpoint %s: %s", endpoint, err.Error())
+		return err
+	}
+
+	defer response.Body.Close()
+
+	return decodeAPIResponse(response, result)
+}
+
+// Helper to call a Telegram API endpoint returning a Message.
+func (b *Bot) makeMessageAPICall(ctx context.Context, endpoint string, v url.Values) (*Message, error) {
+
+	var message Message
+
+	if err := b.makeAPICall(ctx, endpoint, v, &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// Helper to call a Telegram API endpoint returning True on success.
+func (b *Bot) makeBoolAPICall(ctx context.Context, endpoint string, v url.Values) (bool, error) {
+
+	var ok bool
+
+	if err := b.makeAPICall(ctx, endpoint, v, &ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// Build the URL of a Telegram API endpoint.
+func (b *Bot) endpointUrl(endpoint string) string {
+	return b.baseUrl + "/bot" + b.apiToken + endpoint
+}
+
+// Decode the body of a Telegram API response.
+// An *APIError is returned if Telegram answered with ok set to false.
+func decodeAPIResponse(r *http.Response, result interface{}) error {
+
+	var apiResponse APIResponse
+
+	// Try to parse response body.
+	if err := json.NewDecoder(r.Body).Decode(&apiResponse); err != nil {
+
+		// Errors from proxies or overloaded servers may not have a JSON body.
+		if r.StatusCode != http.StatusOK {
+			return &APIError{ErrorCode: r.StatusCode, Description: r.Status}
+		}
+
 		log.Printf("Error when parsing Telegram response: %s", err.Error())
-		return "", nil
+		return err
+	}
+
+	if !apiResponse.Ok {
+		return &APIError{
+			ErrorCode:   apiResponse.ErrorCode,
+			Description: apiResponse.Description,
+			Parameters:  apiResponse.Parameters,
+		}
 	}
 
-	bodyString := string(bodyBytes)
+	// The caller is not interested in the result.
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(apiResponse.Result, result)
+}
+
+// Wait for the wait group wg for at most timeout. Return false if the timeout was reached.
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Sleep for the duration d unless ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Parse a CIDR notation network known to be valid.
+func mustParseCIDR(cidr string) *net.IPNet {
+
+	_, subnet, err := net.ParseCIDR(cidr)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return subnet
+}
+
+// Check if endpoint posts messages, which are subject to the rate limits of Telegram.
+func isSendEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "/send")
+}
+
+// Number of messages posted by a call to the endpoint with the parameters v: one per item of a media group.
+func messageCount(endpoint string, v url.Values) int {
+
+	if endpoint != sendMediaGroupEndpoint {
+		return 1
+	}
+
+	var items []json.RawMessage
+
+	if err := json.Unmarshal([]byte(v.Get("media")), &items); err != nil || len(items) == 0 {
+		return 1
+	}
+
+	return len(items)
+}
+
+// Return a copy of the parameters v.
+func cloneValues(v url.Values) url.Values {
+
+	val := make(url.Values, len(v))
+
+	for key, values := range v {
+		val[key] = append([]string(nil), values...)
+	}
 
-	return bodyString, nil
+	return val
 }
@@ -0,0 +1,174 @@
+package telebot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Reader which cannot seek back to retry an upload.
+type onceReader struct {
+	io.Reader
+}
+
+func TestMultipartRetryOfConsumedReader(t *testing.T) {
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		io.Copy(ioutil.Discard, r.Body)
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"ok":false,"error_code":500,"description":"Internal Server Error"}`))
+	}))
+	defer server.Close()
+
+	b, err := CreateBot("token", WithBaseURL(server.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 5}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := FileFromReader("file", onceReader{strings.NewReader("content")})
+	files := map[string]*InputFile{"document": &file}
+
+	err = b.makeMultipartAPICall(context.Background(), "/sendDocument", url.Values{}, files, nil, nil)
+
+	// The error of the failed attempt is kept.
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != http.StatusInternalServerError {
+		t.Fatalf("makeMultipartAPICall() error = %v, want an *APIError 500", err)
+	}
+
+	if !strings.Contains(err.Error(), "cannot be uploaded again") {
+		t.Errorf("makeMultipartAPICall() error = %q, want the reason of the failed retry", err.Error())
+	}
+
+	// The reader cannot be read again, so the call is not retried any further.
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("%d requests sent, want 1", n)
+	}
+}
+
+func TestMultipartSeekErrorBeforeFirstAttempt(t *testing.T) {
+
+	b, err := CreateBot("token", WithBaseURL("http://127.0.0.1:0"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seekErr := errors.New("seek failed")
+	file := FileFromReader("file", failingSeeker{err: seekErr})
+	files := map[string]*InputFile{"document": &file}
+
+	err = b.makeMultipartAPICall(context.Background(), "/sendDocument", url.Values{}, files, nil, nil)
+
+	if err != seekErr {
+		t.Errorf("makeMultipartAPICall() error = %v, want %v", err, seekErr)
+	}
+}
+
+// Reader whose Seek always fails.
+type failingSeeker struct {
+	io.Reader
+	err error
+}
+
+func (s failingSeeker) Seek(offset int64, whence int) (int64, error) {
+	return 0, s.err
+}
+
+func TestMigratedCallKeepsCallerParameters(t *testing.T) {
+
+	var chatIds []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chatIds = append(chatIds, r.FormValue("chat_id"))
+
+		if r.FormValue("chat_id") == "-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1002}}`))
+			return
+		}
+
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer server.Close()
+
+	b, err := CreateBot("token", WithBaseURL(server.URL))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := url.Values{"chat_id": {"-1"}}
+
+	if err := b.makeAPICall(context.Background(), "/sendMessage", v, nil); err != nil {
+		t.Fatalf("makeAPICall() error = %v", err)
+	}
+
+	if got := strings.Join(chatIds, ","); got != "-1,-1002" {
+		t.Errorf("calls sent to chats %s, want -1,-1002", got)
+	}
+
+	if got := v.Get("chat_id"); got != "-1" {
+		t.Errorf("chat_id of the caller = %s after the migration, want -1", got)
+	}
+}
+
+// Reader blocking in Read until unblock is closed.
+type blockingReader struct {
+	unblock chan struct{}
+}
+
+func (r blockingReader) Read(p []byte) (int, error) {
+	<-r.unblock
+	return 0, io.EOF
+}
+
+func TestMultipartCallCancelledDuringRead(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	b, err := CreateBot("token", WithBaseURL(server.URL))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := blockingReader{unblock: make(chan struct{})}
+	defer close(reader.unblock)
+
+	file := FileFromReader("file", reader)
+	files := map[string]*InputFile{"document": &file}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	returned := make(chan error, 1)
+	go func() {
+		returned <- b.makeMultipartAPICall(ctx, "/sendDocument", url.Values{}, files, nil, nil)
+	}()
+
+	select {
+	case err := <-returned:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("makeMultipartAPICall() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("makeMultipartAPICall() did not return when its context was done")
+	}
+}
@@ -1,68 +1,180 @@
 package telebot
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
-	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 )
 
 // Set the webhook according to the bot config.
-func (b *Bot) setWebhook() (string, error) {
+func (b *Bot) setWebhook(ctx context.Context) (bool, error) {
 
 	// Set the webhook with Telegram /setWebhook API endpoint.
-	res, err := http.PostForm(
-		telegramApiBaseUrl+b.apiToken+setWebhookEndpoint,
-		url.Values{
-			"url":        {b.config["WebhookUrl"] + b.apiToken},
-			"ip_address": {b.config["IPAddress"]},
-		})
+	val := url.Values{
+		"url":                  {b.webhook.Url + b.apiToken},
+		"drop_pending_updates": {strconv.FormatBool(b.dropPendingUpdates)},
+	}
+
+	// IP address used to send requests instead of the one resolved through DNS.
+	if b.webhook.IpAddress != "" {
+		val["ip_address"] = []string{b.webhook.IpAddress}
+	}
+
+	// Secret token sent back by Telegram in every webhook request.
+	if b.secretToken != "" {
+		val["secret_token"] = []string{b.secretToken}
+	}
+
+	// Maximum number of simultaneous connections to the webhook.
+	if b.maxConnections > 0 {
+		val["max_connections"] = []string{strconv.Itoa(b.maxConnections)}
+	}
+
+	// Types of updates to receive.
+	if err := b.setAllowedUpdates(val); err != nil {
+		return false, err
+	}
+
+	// Upload the public key certificate of a self-signed certificate.
+	if b.webhookCertificate != "" {
+		var ok bool
+		certificate := FileFromPath(b.webhookCertificate)
+		err := b.makeMultipartAPICall(ctx, setWebhookEndpoint, val, map[string]*InputFile{"certificate": &certificate}, nil, &ok)
+
+		return ok, err
+	}
+
+	return b.makeBoolAPICall(ctx, setWebhookEndpoint, val)
+}
+
+// Register the webhook with Telegram and open the listener of the webhook server.
+// Return the function serving the webhook until its context is done.
+func (b *Bot) startWebhook(ctx context.Context) (func(ctx context.Context) error, error) {
+
+	path, err := b.WebhookPath()
 
 	if err != nil {
-		log.Printf("Error when posting to webhook endpoint: %s", err.Error())
-		return "", err
+		return nil, err
+	}
+
+	// Set up webhook.
+	if _, err := b.setWebhook(ctx); err != nil {
+		return nil, err
+	}
+
+	// Updates are routed to WebhookHandler by the caller.
+	if b.noWebhookServer {
+		return func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}, nil
+	}
+
+	server := b.webhookServer
+	if server == nil {
+		server = &http.Server{}
+	}
+
+	if server.Addr == "" {
+		server.Addr = b.listenAddr
+	}
+
+	// Serve the webhook handler on the webhook path, unless the server has its own routes.
+	if server.Handler == nil {
+		mux := http.NewServeMux()
+		mux.Handle(path, b.WebhookHandler())
+		server.Handler = mux
 	}
 
-	defer res.Body.Close()
+	// Listen now so that an unavailable address is reported by Start.
+	listener, err := net.Listen("tcp", server.Addr)
 
-	bodyBytes, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		log.Printf("Error when parsing Telegram response: %s", err.Error())
-		return "", nil
+		return nil, err
 	}
 
-	bodyString := string(bodyBytes)
+	return func(ctx context.Context) error {
+		return b.serveWebhook(ctx, server, listener)
+	}, nil
+}
+
+// Serve the webhook until ctx is done, then shut the server down.
+func (b *Bot) serveWebhook(ctx context.Context, server *http.Server, listener net.Listener) error {
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		// Behind a reverse proxy, TLS is terminated by the proxy.
+		if b.plainHTTP {
+			serveErr <- server.Serve(listener)
+		} else {
+			serveErr <- server.ServeTLS(listener, b.webhook.SslCertificate, b.webhook.SslPrivkey)
+		}
+	}()
 
-	return bodyString, nil
+	select {
+	case err := <-serveErr:
+		log.Printf("Error serving webhook: %s", err.Error())
+		return err
+	case <-ctx.Done():
+	}
+
+	// Stop accepting requests and wait for active ones with a deadline.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), b.shutdownTimeout)
+	defer cancel()
+
+	return server.Shutdown(shutdownCtx)
 }
 
-// Delete Bot webhook with the Telegram /deleteWebhook API endpoint.
-func (b *Bot) deleteWebhook() (string, error) {
+// Return the path of the webhook URL on which Telegram sends updates.
+func (b *Bot) WebhookPath() (string, error) {
 
-	res, err := http.PostForm(
-		telegramApiBaseUrl+b.apiToken+deleteWebhookEndpoint,
-		url.Values{},
-	)
+	u, err := url.Parse(b.webhook.Url + b.apiToken)
 
 	if err != nil {
-		log.Printf("Error when delete webhook: %s", err.Error())
 		return "", err
 	}
 
-	defer res.Body.Close()
+	return u.Path, nil
+}
 
-	bodyBytes, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		log.Printf("Error when parsing Telegram response: %s", err.Error())
-		return "", nil
+// Return the http.Handler receiving the updates sent by Telegram to the webhook.
+// It can be mounted on the webhook path in any router, along with the WithoutWebhookServer option.
+// Updates are only accepted while the bot is started.
+func (b *Bot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(b.handleTelegramWebHook)
+}
+
+// Delete Bot webhook with the Telegram /deleteWebhook API endpoint.
+func (b *Bot) deleteWebhook(ctx context.Context) (bool, error) {
+
+	val := url.Values{
+		"drop_pending_updates": {strconv.FormatBool(b.dropPendingUpdates)},
 	}
 
-	bodyString := string(bodyBytes)
+	return b.makeBoolAPICall(ctx, deleteWebhookEndpoint, val)
+}
+
+// Get the current status of the webhook.
+func (b *Bot) GetWebhookInfo() (*WebhookInfo, error) {
+	return b.GetWebhookInfoCtx(context.Background())
+}
+
+// GetWebhookInfo with a context controlling the call.
+func (b *Bot) GetWebhookInfoCtx(ctx context.Context) (*WebhookInfo, error) {
 
-	log.Printf("Body of Telegram response: %s", bodyString)
+	var webhookInfo WebhookInfo
 
-	return bodyString, nil
+	if err := b.makeAPICall(ctx, getWebhookInfoEndpoint, url.Values{}, &webhookInfo); err != nil {
+		return nil, err
+	}
+
+	return &webhookInfo, nil
 }
 
 // Parse the request body of the Telegram webhook.
@@ -80,14 +192,68 @@ func parseTelegramWebhookRequest(r *http.Request) (*Update, error) {
 // Handle the webhook http request from Telegram.
 func (b *Bot) handleTelegramWebHook(w http.ResponseWriter, r *http.Request) {
 
+	// Telegram only sends updates with POST requests.
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Reject requests which do not come from the allowed networks.
+	if len(b.sourceAllowlist) > 0 && !isAllowedSource(r.RemoteAddr, b.sourceAllowlist) {
+		log.Printf("Rejected webhook request from %s", r.RemoteAddr)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	// Telegram sends the secret token set with the webhook in every request.
+	if b.secretToken != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(b.secretToken)) != 1 {
+		log.Printf("Rejected webhook request with an invalid secret token from %s", r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Bound the size of the body.
+	r.Body = http.MaxBytesReader(w, r.Body, b.maxBodySize)
+
 	// parse Update object
 	update, err := parseTelegramWebhookRequest(r)
 	if err != nil {
 		log.Printf("Error parsing update, %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	// Dispatch update.
-	b.dispatchUpdate(update)
+	// Queue the update in the dispatcher.
+	// Telegram delivers the update again if the request fails.
+	if !b.enqueueUpdate(r.Context(), update) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Check if the remote address of a request belongs to one of the allowed networks.
+func isAllowedSource(remoteAddr string, allowlist []*net.IPNet) bool {
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		return false
+	}
+
+	for _, subnet := range allowlist {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
 
+	return false
 }
@@ -0,0 +1,90 @@
+package telebot
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookHandler(t *testing.T) {
+
+	_, allowed, _ := net.ParseCIDR("149.154.160.0/20")
+
+	newBot := func(t *testing.T, opts ...Option) *Bot {
+
+		opts = append([]Option{WithWebhook(WebhookConfig{Url: "https://example.com/hook"}), WithPlainHTTP()}, opts...)
+		b, err := CreateBot("token", opts...)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return b
+	}
+
+	update := `{"update_id":1,"message":{"message_id":1,"chat":{"id":1},"text":"hello"}}`
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		method     string
+		remoteAddr string
+		token      *string
+		body       string
+		// Run a dispatcher, as Start does.
+		started bool
+		want    int
+	}{
+		{"update", nil, http.MethodPost, "", nil, update, true, http.StatusOK},
+		{"not started", nil, http.MethodPost, "", nil, update, false, http.StatusServiceUnavailable},
+		{"get", nil, http.MethodGet, "", nil, "", true, http.StatusMethodNotAllowed},
+		{"invalid body", nil, http.MethodPost, "", nil, "{", true, http.StatusBadRequest},
+		{"body too large", []Option{WithMaxBodySize(16)}, http.MethodPost, "", nil, update, true, http.StatusBadRequest},
+		{"secret token", []Option{WithSecretToken("secret")}, http.MethodPost, "", strPtr("secret"), update, true, http.StatusOK},
+		{"wrong secret token", []Option{WithSecretToken("secret")}, http.MethodPost, "", strPtr("secreT"), update, true, http.StatusUnauthorized},
+		{"secret token prefix", []Option{WithSecretToken("secret")}, http.MethodPost, "", strPtr("secret2"), update, true, http.StatusUnauthorized},
+		{"empty secret token", []Option{WithSecretToken("secret")}, http.MethodPost, "", strPtr(""), update, true, http.StatusUnauthorized},
+		{"missing secret token", []Option{WithSecretToken("secret")}, http.MethodPost, "", nil, update, true, http.StatusUnauthorized},
+		{"allowed source", []Option{WithSourceAllowlist(allowed)}, http.MethodPost, "149.154.167.1:443", nil, update, true, http.StatusOK},
+		{"forbidden source", []Option{WithSourceAllowlist(allowed)}, http.MethodPost, "203.0.113.1:443", nil, update, true, http.StatusForbidden},
+		{"invalid source", []Option{WithSourceAllowlist(allowed)}, http.MethodPost, "unknown", nil, update, true, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := newBot(t, test.opts...)
+
+			if test.started {
+				d := b.startDispatcher(context.Background(), 1, 1)
+				defer d.close()
+				b.dispatcher = d
+			}
+
+			request := httptest.NewRequest(test.method, "/hook", strings.NewReader(test.body))
+			if test.remoteAddr != "" {
+				request.RemoteAddr = test.remoteAddr
+			}
+			if test.token != nil {
+				request.Header.Set(secretTokenHeader, *test.token)
+			}
+
+			recorder := httptest.NewRecorder()
+			b.WebhookHandler().ServeHTTP(recorder, request)
+
+			if recorder.Code != test.want {
+				t.Errorf("status = %d, want %d", recorder.Code, test.want)
+			}
+
+			if test.want == http.StatusMethodNotAllowed && recorder.Header().Get("Allow") != http.MethodPost {
+				t.Errorf("Allow header = %q, want %q", recorder.Header().Get("Allow"), http.MethodPost)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}